@@ -0,0 +1,149 @@
+package flaggy_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/integrii/flaggy"
+)
+
+// These tests parse with a shared parser from many goroutines at once.  Run
+// them with go test -race to detect unsafe access to the definition.
+
+func TestParseArgsIntoConcurrently(t *testing.T) {
+	t.Parallel()
+
+	p := flaggy.NewParser("bot")
+	flaggy.AddP(&p.Subcommand, "u", "user", "nobody", "user flag")
+	flaggy.AddP[[]string](&p.Subcommand, "t", "tag", []string{"default"}, "tag flag")
+	p.String(new(string), "b", "body", "body flag")
+	p.AllowFileValues = true
+	deploy := flaggy.NewSubcommand("deploy")
+	flaggy.AddP(deploy, "r", "replicas", 1, "replicas flag")
+	deploy.StringMap(new(map[string]string), "l", "label", "label flag")
+	var service string
+	deploy.AddPositionalValue(&service, "service", 1, true, "service positional")
+	p.AttachSubcommand(deploy, 1)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n := strconv.Itoa(i)
+			args := []string{"-u", "user" + n, "-t", n, "deploy", "svc" + n, "--replicas", n, "-l", "id=" + n, "-b", "@@" + n, "--", n}
+			r, err := p.ParseArgsInto(args)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			// write the values into destinations owned by this goroutine
+			var user, body, svc string
+			var tags []string
+			var replicas int
+			var labels map[string]string
+			for name, dest := range map[string]interface{}{"user": &user, "tag": &tags, "replicas": &replicas, "label": &labels, "body": &body, "service": &svc} {
+				err = r.Assign(name, dest)
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			if user != "user"+n || body != "@"+n || svc != "svc"+n || replicas != i ||
+				len(tags) != 2 || tags[0] != "default" || tags[1] != n ||
+				len(labels) != 1 || labels["id"] != n || r.TrailingArguments()[0] != n {
+				t.Error("unexpected values for parse", n, user, body, svc, replicas, tags, labels)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// the shared definition was not changed
+	if deploy.Used || service != "" || p.Changed("user") {
+		t.Fatal("concurrent parses changed the parser")
+	}
+}
+
+func TestParseArgsIntoConcurrentlyWithHelpAndErrors(t *testing.T) {
+	t.Parallel()
+
+	p := flaggy.NewParser("bot")
+	flaggy.AddP(&p.Subcommand, "c", "count", 0, "count flag")
+	status := flaggy.NewSubcommand("status")
+	p.AttachSubcommand(status, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			r, err := p.ParseArgsInto([]string{"status", "--help"})
+			if err != nil || !r.HelpRequested() || r.Subcommand() != status {
+				t.Error("help request not reported", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := p.ParseArgsInto([]string{"--count", "many"})
+			if err == nil {
+				t.Error("expected an error for an invalid count")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			r, err := p.ParseArgsInto([]string{"unknown", "--other=x"})
+			if err != nil || len(r.Unknown()) != 2 {
+				t.Error("unknown args not reported", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseArgsIntoConcurrentlyFromInput(t *testing.T) {
+	t.Parallel()
+
+	p := flaggy.NewParser("bot")
+	p.String(new(string), "b", "body", "body flag")
+	p.AllowFileValues = true
+	p.SetInput(strings.NewReader("secret\n"))
+
+	// input is read by one parse at a time, so only one of them finds it
+	var wg sync.WaitGroup
+	bodies := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := p.ParseArgsInto([]string{"-b", "@-"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body, _ := flaggy.ResultValue[string](r, "body")
+			bodies <- body
+		}()
+	}
+	wg.Wait()
+	close(bodies)
+
+	var found int
+	for body := range bodies {
+		if body == "secret" {
+			found++
+		} else if body != "" {
+			t.Fatal("unexpected body read from input:", body)
+		}
+	}
+	if found != 1 {
+		t.Fatal("expected input to be read by one parse but it was read by", found)
+	}
+}
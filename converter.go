@@ -0,0 +1,562 @@
+package flaggy
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter converts the string values supplied on the command line to a
+// flag type, and formats values of that type for help output.  Registering a
+// Converter with RegisterConverter makes both the type and a slice of the
+// type usable as flags with Add.
+type Converter[T any] struct {
+	Parse  func(value string) (T, error) // converts a single command line value
+	Format func(value T) string          // formats a value for help output.  Uses fmt.Sprint when nil.
+	Hint   string                        // the value placeholder in help, such as "int".  Slices add an "s".
+}
+
+// typeConverter assigns and formats the values of a flag's AssignmentVar.
+// Every registered Converter is stored as one typeConverter for the type and
+// another for a slice of the type.
+type typeConverter interface {
+	assign(f *Flag, value string) error
+	format(f *Flag) string
+	hint() string
+	isSlice() bool
+}
+
+// flagConverter converts values like a Converter, but is also passed the flag
+// being parsed.  Types flaggy supports natively use it to honor options of the
+// flag, such as the layout of time flags.
+type flagConverter[T any] struct {
+	Parse  func(f *Flag, value string) (T, error)
+	Format func(f *Flag, value T) string
+	Hint   string
+}
+
+// valueConverter assigns values to a *T
+type valueConverter[T any] flagConverter[T]
+
+func (c valueConverter[T]) assign(f *Flag, value string) error {
+	v, err := c.Parse(f, value)
+	if err != nil {
+		return err
+	}
+	*f.AssignmentVar.(*T) = v
+	return nil
+}
+
+func (c valueConverter[T]) format(f *Flag) string {
+	return c.Format(f, *f.AssignmentVar.(*T))
+}
+
+func (c valueConverter[T]) hint() string {
+	return c.Hint
+}
+
+func (c valueConverter[T]) isSlice() bool {
+	return false
+}
+
+// sliceConverter appends values of type T to a *S, where S is a slice of T
+type sliceConverter[S ~[]T, T any] flagConverter[T]
+
+func (c sliceConverter[S, T]) assign(f *Flag, value string) error {
+	v, err := c.Parse(f, value)
+	if err != nil {
+		return err
+	}
+	existing := f.AssignmentVar.(*S)
+	*existing = append(*existing, v)
+	return nil
+}
+
+func (c sliceConverter[S, T]) format(f *Flag) string {
+	var strSlice []string
+	for _, v := range *f.AssignmentVar.(*S) {
+		strSlice = append(strSlice, c.Format(f, v))
+	}
+	return strings.Join(strSlice, ",")
+}
+
+func (c sliceConverter[S, T]) hint() string {
+	if c.Hint == "" {
+		return ""
+	}
+	return c.Hint + "s"
+}
+
+func (c sliceConverter[S, T]) isSlice() bool {
+	return true
+}
+
+// mapConverter adds key=value pairs to a *map[string]V, converting each value
+// to a V.  Pairs are split and checked for duplicate keys by the flag.
+type mapConverter[V any] flagConverter[V]
+
+func (c mapConverter[V]) assign(f *Flag, value string) error {
+	entries, err := f.parseMapEntries(value)
+	if err != nil {
+		return err
+	}
+	existing := f.AssignmentVar.(*map[string]V)
+	if *existing == nil {
+		*existing = make(map[string]V)
+	}
+	for _, e := range entries {
+		v, err := c.Parse(f, e[1])
+		if err != nil {
+			return err
+		}
+		(*existing)[e[0]] = v
+	}
+	return nil
+}
+
+func (c mapConverter[V]) format(f *Flag) string {
+	existing := *f.AssignmentVar.(*map[string]V)
+	var strSlice []string
+	for _, k := range sortedMapKeys(existing) {
+		strSlice = append(strSlice, k+"="+c.Format(f, existing[k]))
+	}
+	return strings.Join(strSlice, ",")
+}
+
+func (c mapConverter[V]) hint() string {
+	return "key=" + c.Hint
+}
+
+func (c mapConverter[V]) isSlice() bool {
+	return false
+}
+
+// formatValue formats a single value with the converter's Format func
+func (c Converter[T]) formatValue(value T) string {
+	if c.Format == nil {
+		return fmt.Sprint(value)
+	}
+	return c.Format(value)
+}
+
+// withFlag returns a flagConverter that converts values the same way as the
+// Converter, without using the flag
+func (c Converter[T]) withFlag() flagConverter[T] {
+	return flagConverter[T]{
+		Parse: func(f *Flag, value string) (T, error) {
+			return c.Parse(value)
+		},
+		Format: func(f *Flag, value T) string {
+			return c.formatValue(value)
+		},
+		Hint: c.Hint,
+	}
+}
+
+// converters holds every registered converter keyed by the pointer type of
+// the AssignmentVar it handles
+var converters = map[reflect.Type]typeConverter{}
+var convertersLock sync.RWMutex
+
+// RegisterConverter registers a converter for the type T and for slices of
+// T.  The types flaggy supports natively are registered the same way, so
+// registering one of them changes how it is parsed.  Registering the same type
+// again replaces the earlier converter.
+func RegisterConverter[T any](c Converter[T]) {
+	if c.Parse == nil {
+		log.Panicln("Converter registered for type " + reflect.TypeOf((*T)(nil)).Elem().String() + " without a Parse func.")
+	}
+	registerConverter[T, []T](c.withFlag())
+}
+
+// registerConverter registers a converter for the type T and for the slice
+// type S of T
+func registerConverter[T any, S ~[]T](c flagConverter[T]) {
+	registerTypeConverter[T](valueConverter[T](c))
+	registerTypeConverter[S](sliceConverter[S, T](c))
+}
+
+// registerTypeConverter registers the converter for an AssignmentVar of type
+// *T
+func registerTypeConverter[T any](c typeConverter) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converters[reflect.TypeOf((*T)(nil))] = c
+}
+
+// lookupConverter returns the registered converter for the supplied
+// AssignmentVar, if there is one
+func lookupConverter(assignmentVar interface{}) (typeConverter, bool) {
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+	c, ok := converters[reflect.TypeOf(assignmentVar)]
+	return c, ok
+}
+
+// supportsType determines if the supplied AssignmentVar can be used as a
+// flag, either natively or through a registered converter
+func supportsType(assignmentVar interface{}) bool {
+	_, ok := lookupConverter(assignmentVar)
+	return ok
+}
+
+// Add adds a new flag of any supported type to the subcommand.  The type can
+// be any of the types flaggy supports natively, or one with a converter
+// registered by RegisterConverter.  The flag is added to the default parser
+// when sc is nil.  Add panics when the type is not supported.
+//
+//	var port int
+//	flaggy.Add(nil, &port, "p", "port", "The port to listen on")
+func Add[T any](sc *Subcommand, assignmentVar *T, shortName string, longName string, description string) {
+	addTyped(sc, assignmentVar, shortName, longName, description)
+}
+
+// AddP adds a new flag of any supported type to the subcommand, like Add, but
+// allocates the variable itself.  The variable starts with a copy of the
+// specified default value, which is recorded for help output, and a pointer
+// to it is returned.  Parsing never changes a default slice or map.
+//
+//	port := flaggy.AddP(nil, "p", "port", 8080, "The port to listen on")
+func AddP[T any](sc *Subcommand, shortName string, longName string, defaultValue T, description string) *T {
+	assignmentVar := new(T)
+	reflect.ValueOf(assignmentVar).Elem().Set(copyValue(reflect.ValueOf(&defaultValue).Elem()))
+	f := addTyped(sc, assignmentVar, shortName, longName, description)
+	f.recordDefault()
+	return assignmentVar
+}
+
+// addTyped adds a flag of a supported type to the subcommand, or to the
+// default parser when sc is nil
+func addTyped[T any](sc *Subcommand, assignmentVar *T, shortName string, longName string, description string) *Flag {
+	if sc == nil {
+		sc = &DefaultParser.Subcommand
+	}
+	if !supportsType(assignmentVar) {
+		log.Panicln("Flag " + longName + " added to subcommand " + sc.Name + " with unsupported type " + reflect.TypeOf(assignmentVar).Elem().String() + ".")
+	}
+	return sc.add(assignmentVar, shortName, longName, description)
+}
+
+// Get returns a copy of the current value of the flag with the specified
+// short or long name on the subcommand.  The default parser is used when sc
+// is nil.  An error is returned when the flag is not found or T is not the
+// type accepted by the flag's constructor, such as []byte for HexBytes.
+func Get[T any](sc *Subcommand, name string) (T, error) {
+	var zero T
+	if sc == nil {
+		sc = &DefaultParser.Subcommand
+	}
+	for _, f := range sc.Flags {
+		if !f.HasName(name) {
+			continue
+		}
+		return flagValueAs[T](f, name)
+	}
+	return zero, errors.New("Flag " + name + " not found in subcommand " + sc.Name)
+}
+
+// intConverter creates a converter for a signed integer type of the
+// specified bit size
+func intConverter[T int | int8 | int16 | int32 | int64](bitSize int) Converter[T] {
+	return Converter[T]{
+		Parse: func(value string) (T, error) {
+			v, err := strconv.ParseInt(value, 10, bitSize)
+			return T(v), err
+		},
+		Format: func(value T) string {
+			return strconv.FormatInt(int64(value), 10)
+		},
+		Hint: "int",
+	}
+}
+
+// uintConverter creates a converter for an unsigned integer type of the
+// specified bit size
+func uintConverter[T uint | uint8 | uint16 | uint32 | uint64](bitSize int) Converter[T] {
+	return Converter[T]{
+		Parse: func(value string) (T, error) {
+			v, err := strconv.ParseUint(value, 10, bitSize)
+			return T(v), err
+		},
+		Format: func(value T) string {
+			return strconv.FormatUint(uint64(value), 10)
+		},
+		Hint: "uint",
+	}
+}
+
+// floatConverter creates a converter for a float type of the specified bit
+// size.  Help output displays two decimal places.
+func floatConverter[T float32 | float64](bitSize int) Converter[T] {
+	return Converter[T]{
+		Parse: func(value string) (T, error) {
+			v, err := strconv.ParseFloat(value, bitSize)
+			return T(v), err
+		},
+		Format: func(value T) string {
+			return strconv.FormatFloat(float64(value), 'f', 2, bitSize)
+		},
+		Hint: "float",
+	}
+}
+
+// pathConverter creates a converter for a path type that expands the path
+// with the flag's options, then checks it with validate when it is not nil.
+// The kind of path is displayed when it does not pass the check.
+func pathConverter[T ~string](hint string, kind string, validate func(path string) error) flagConverter[T] {
+	return flagConverter[T]{
+		Parse: func(f *Flag, value string) (T, error) {
+			v, err := f.expandPath(value)
+			if err != nil {
+				return "", err
+			}
+			if validate != nil {
+				err = validate(v)
+				if err != nil {
+					return "", errors.New("Invalid " + kind + " supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
+				}
+			}
+			return T(v), nil
+		},
+		Format: func(f *Flag, value T) string {
+			return string(value)
+		},
+		Hint: hint,
+	}
+}
+
+// register the types supported natively
+func init() {
+	RegisterConverter(intConverter[int](0))
+	RegisterConverter(intConverter[int8](8))
+	RegisterConverter(intConverter[int16](16))
+	RegisterConverter(intConverter[int32](32))
+	RegisterConverter(intConverter[int64](64))
+	RegisterConverter(uintConverter[uint](0))
+	RegisterConverter(uintConverter[uint8](8))
+	RegisterConverter(uintConverter[uint16](16))
+	RegisterConverter(uintConverter[uint32](32))
+	RegisterConverter(uintConverter[uint64](64))
+	RegisterConverter(floatConverter[float32](32))
+	RegisterConverter(floatConverter[float64](64))
+
+	RegisterConverter(Converter[string]{
+		Parse: func(value string) (string, error) {
+			return value, nil
+		},
+		Hint: "string",
+	})
+	RegisterConverter(Converter[bool]{
+		Parse:  strconv.ParseBool,
+		Format: strconv.FormatBool,
+	})
+	RegisterConverter(Converter[time.Duration]{
+		Parse: time.ParseDuration,
+		Format: func(value time.Duration) string {
+			return value.String()
+		},
+		Hint: "duration",
+	})
+	registerConverter[time.Time, []time.Time](flagConverter[time.Time]{
+		Parse: func(f *Flag, value string) (time.Time, error) {
+			return parseTime(value, f.layout())
+		},
+		Format: func(f *Flag, value time.Time) string {
+			if value.IsZero() {
+				return ""
+			}
+			return value.Format(f.layout())
+		},
+		Hint: "time",
+	})
+
+	// byte sizes and percentages are added as their underlying types, so
+	// slices of them are not slices of the named type
+	registerTypeConverter[byteSize](valueConverter[byteSize](Converter[byteSize]{
+		Parse: func(value string) (byteSize, error) {
+			v, err := parseByteSize(value)
+			return byteSize(v), err
+		},
+		Format: func(value byteSize) string {
+			return formatByteSize(uint64(value))
+		},
+		Hint: "size",
+	}.withFlag()))
+	registerTypeConverter[byteSizeSlice](sliceConverter[byteSizeSlice, uint64](Converter[uint64]{
+		Parse:  parseByteSize,
+		Format: formatByteSize,
+		Hint:   "size",
+	}.withFlag()))
+	registerTypeConverter[percentage](valueConverter[percentage](Converter[percentage]{
+		Parse: func(value string) (percentage, error) {
+			v, err := parsePercentage(value)
+			return percentage(v), err
+		},
+		Format: func(value percentage) string {
+			return formatPercentage(float64(value))
+		},
+		Hint: "percent",
+	}.withFlag()))
+	registerTypeConverter[percentageSlice](sliceConverter[percentageSlice, float64](Converter[float64]{
+		Parse:  parsePercentage,
+		Format: formatPercentage,
+		Hint:   "percent",
+	}.withFlag()))
+
+	registerTypeConverter[map[string]string](mapConverter[string](Converter[string]{
+		Parse: func(value string) (string, error) {
+			return value, nil
+		},
+		Hint: "value",
+	}.withFlag()))
+	registerTypeConverter[map[string]int](mapConverter[int](Converter[int]{
+		Parse:  strconv.Atoi,
+		Format: strconv.Itoa,
+		Hint:   "int",
+	}.withFlag()))
+	registerTypeConverter[map[string]time.Duration](mapConverter[time.Duration](Converter[time.Duration]{
+		Parse: time.ParseDuration,
+		Format: func(value time.Duration) string {
+			return value.String()
+		},
+		Hint: "duration",
+	}.withFlag()))
+
+	registerTypeConverter[filePath](valueConverter[filePath](pathConverter[filePath]("file", "", nil)))
+	registerTypeConverter[existingFilePath](valueConverter[existingFilePath](pathConverter[existingFilePath]("file", "file", validateExistingFile)))
+	registerTypeConverter[existingDirPath](valueConverter[existingDirPath](pathConverter[existingDirPath]("dir", "directory", validateExistingDir)))
+	registerTypeConverter[outputFilePath](valueConverter[outputFilePath](pathConverter[outputFilePath]("file", "output file", validateOutputFile)))
+
+	registerTypeConverter[hexBytes](valueConverter[hexBytes](flagConverter[hexBytes]{
+		Parse: func(f *Flag, value string) (hexBytes, error) {
+			v, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+			if err != nil {
+				return nil, errors.New("Invalid hex value supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
+			}
+			return v, nil
+		},
+		Format: func(f *Flag, value hexBytes) string {
+			return hex.EncodeToString(value)
+		},
+		Hint: "hex",
+	}))
+	registerTypeConverter[base64Bytes](valueConverter[base64Bytes](flagConverter[base64Bytes]{
+		Parse: func(f *Flag, value string) (base64Bytes, error) {
+			v, err := decodeBase64(value)
+			if err != nil {
+				return nil, errors.New("Invalid base64 value supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
+			}
+			return v, nil
+		},
+		Format: func(f *Flag, value base64Bytes) string {
+			return base64.StdEncoding.EncodeToString(value)
+		},
+		Hint: "base64",
+	}))
+	registerTypeConverter[fileBytes](valueConverter[fileBytes](flagConverter[fileBytes]{
+		Parse: func(f *Flag, value string) (fileBytes, error) {
+			v, err := ioutil.ReadFile(value)
+			if err != nil {
+				return nil, errors.New("Unable to read file supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
+			}
+			return v, nil
+		},
+		// file contents are not displayed in help
+		Format: func(f *Flag, value fileBytes) string {
+			return ""
+		},
+		Hint: "file",
+	}))
+
+	RegisterConverter(Converter[net.IP]{
+		Parse: parseIP,
+		Format: func(value net.IP) string {
+			return value.String()
+		},
+		Hint: "ip",
+	})
+	RegisterConverter(Converter[net.HardwareAddr]{
+		Parse: net.ParseMAC,
+		Format: func(value net.HardwareAddr) string {
+			return value.String()
+		},
+		Hint: "mac",
+	})
+	RegisterConverter(Converter[net.IPMask]{
+		Parse: parseIPMask,
+		Format: func(value net.IPMask) string {
+			return value.String()
+		},
+		Hint: "mask",
+	})
+	RegisterConverter(Converter[net.IPNet]{
+		Parse: func(value string) (net.IPNet, error) {
+			_, v, err := net.ParseCIDR(value)
+			if err != nil {
+				return net.IPNet{}, err
+			}
+			return *v, nil
+		},
+		Format: func(value net.IPNet) string {
+			if value.IP == nil {
+				return ""
+			}
+			return value.String()
+		},
+		Hint: "cidr",
+	})
+	RegisterConverter(Converter[net.TCPAddr]{
+		Parse: parseTCPAddr,
+		Format: func(value net.TCPAddr) string {
+			if value.IP == nil && value.Port == 0 {
+				return ""
+			}
+			return value.String()
+		},
+		Hint: "host:port",
+	})
+	RegisterConverter(Converter[netip.Addr]{
+		Parse: netip.ParseAddr,
+		Format: func(value netip.Addr) string {
+			if !value.IsValid() {
+				return ""
+			}
+			return value.String()
+		},
+		Hint: "ip",
+	})
+	RegisterConverter(Converter[netip.Prefix]{
+		Parse: netip.ParsePrefix,
+		Format: func(value netip.Prefix) string {
+			if !value.IsValid() {
+				return ""
+			}
+			return value.String()
+		},
+		Hint: "cidr",
+	})
+	RegisterConverter(Converter[url.URL]{
+		Parse: func(value string) (url.URL, error) {
+			u, err := parseURL(value)
+			if err != nil {
+				return url.URL{}, err
+			}
+			return *u, nil
+		},
+		Format: func(value url.URL) string {
+			return value.String()
+		},
+		Hint: "url",
+	})
+}
@@ -0,0 +1,296 @@
+package flaggy
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// level is a custom flag type used to test registered converters
+type level int
+
+func registerLevelConverter() {
+	levels := []string{"debug", "info", "warn"}
+	RegisterConverter(Converter[level]{
+		Parse: func(value string) (level, error) {
+			for i, l := range levels {
+				if strings.EqualFold(l, value) {
+					return level(i), nil
+				}
+			}
+			return 0, errors.New("unknown level " + value)
+		},
+		Format: func(value level) string {
+			return levels[value]
+		},
+		Hint: "level",
+	})
+}
+
+func TestRegisterConverter(t *testing.T) {
+	registerLevelConverter()
+
+	var lvl level
+	var lvls []level
+	p := NewParser("testRegisterConverter")
+	Add(&p.Subcommand, &lvl, "l", "level", "level flag")
+	Add(&p.Subcommand, &lvls, "ls", "levels", "levels flag")
+	err := p.ParseArgs([]string{"-l", "WARN", "-ls", "debug,info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lvl != 2 {
+		t.Fatal("level flag incorrect", lvl)
+	}
+	if len(lvls) != 2 || lvls[1] != 1 {
+		t.Fatal("levels flag incorrect", lvls)
+	}
+
+	f := p.Flags[1]
+	if f.typeHint() != "levels" || !f.isSliceFlag() {
+		t.Fatal("levels flag hint incorrect", f.typeHint())
+	}
+	formatted, err := f.returnAssignmentVarValueAsString()
+	if err != nil || formatted != "debug,info" {
+		t.Fatal("levels flag formatted incorrectly", formatted, err)
+	}
+
+	p = NewParser("testRegisterConverterInvalid")
+	Add(&p.Subcommand, &lvl, "l", "level", "level flag")
+	err = p.ParseArgs([]string{"-l", "loud"})
+	if err == nil {
+		t.Fatal("expected an error parsing an invalid level")
+	}
+}
+
+func TestNativeTypesAreConverters(t *testing.T) {
+	hints := map[interface{}]string{
+		new(string):                   "string",
+		new([]bool):                   "",
+		new([]time.Duration):          "durations",
+		new(time.Time):                "time",
+		new(byteSizeSlice):            "sizes",
+		new(percentage):               "percent",
+		new(map[string]int):           "key=int",
+		new(existingDirPath):          "dir",
+		new(hexBytes):                 "hex",
+		new([]net.IPMask):             "masks",
+		new(net.TCPAddr):              "host:port",
+		new([]netip.Prefix):           "cidrs",
+		new(url.URL):                  "url",
+		new(map[string]time.Duration): "key=duration",
+	}
+	for assignmentVar, hint := range hints {
+		c, ok := lookupConverter(assignmentVar)
+		if !ok {
+			t.Fatal("no converter registered for", reflect.TypeOf(assignmentVar))
+		}
+		if c.hint() != hint {
+			t.Fatal("unexpected hint for", reflect.TypeOf(assignmentVar), c.hint())
+		}
+	}
+}
+
+func TestAddAndGet(t *testing.T) {
+	var port int
+	var ratio float32
+	var hosts []string
+	p := NewParser("testAddAndGet")
+	Add(&p.Subcommand, &port, "p", "port", "port flag")
+	Add(&p.Subcommand, &ratio, "r", "ratio", "ratio flag")
+	Add(&p.Subcommand, &hosts, "", "host", "host flag")
+	err := p.ParseArgs([]string{"-p", "8080", "--ratio", "0.5", "--host", "a,b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotPort, err := Get[int](&p.Subcommand, "port")
+	if err != nil || gotPort != 8080 {
+		t.Fatal("port flag incorrect", gotPort, err)
+	}
+	gotRatio, err := Get[float32](&p.Subcommand, "r")
+	if err != nil || gotRatio != 0.5 {
+		t.Fatal("ratio flag incorrect", gotRatio, err)
+	}
+	gotHosts, err := Get[[]string](&p.Subcommand, "host")
+	if err != nil || len(gotHosts) != 2 {
+		t.Fatal("host flag incorrect", gotHosts, err)
+	}
+
+	_, err = Get[string](&p.Subcommand, "port")
+	if err == nil {
+		t.Fatal("expected an error getting a flag as the wrong type")
+	}
+	_, err = Get[int](&p.Subcommand, "missing")
+	if err == nil {
+		t.Fatal("expected an error getting a missing flag")
+	}
+}
+
+func TestAddUnsupportedType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic adding an unsupported flag type")
+		}
+	}()
+	var unsupported struct{}
+	p := NewParser("testAddUnsupportedType")
+	Add(&p.Subcommand, &unsupported, "u", "unsupported", "unsupported flag")
+}
+
+func TestAddP(t *testing.T) {
+	p := NewParser("testAddP")
+	port := AddP(&p.Subcommand, "p", "port", 8080, "port flag")
+	name := AddP(&p.Subcommand, "n", "name", "flaggy", "name flag")
+	tags := AddP[[]string](&p.Subcommand, "t", "tag", []string{"a"}, "tag flag")
+	registerLevelConverter()
+	level := AddP(&p.Subcommand, "l", "level", level(1), "level flag")
+	err := p.ParseArgs([]string{"-p", "9090", "-t", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 9090 {
+		t.Fatal("port flag incorrect", *port)
+	}
+	if *name != "flaggy" {
+		t.Fatal("name flag lost its default", *name)
+	}
+	if len(*tags) != 2 || (*tags)[1] != "b" {
+		t.Fatal("tag flag incorrect", *tags)
+	}
+	if *level != 1 {
+		t.Fatal("level flag lost its default", *level)
+	}
+
+	// help displays the defaults supplied when the flags were added
+	h := Help{}
+	h.ExtractValues(p, "")
+	defaults := map[string]string{}
+	for _, f := range h.Flags {
+		defaults[f.LongName] = f.DefaultValue
+	}
+	if defaults["port"] != "8080" || defaults["name"] != "flaggy" || defaults["tag"] != "a" {
+		t.Fatal("help defaults incorrect", defaults)
+	}
+}
+
+func TestAddPCopiesDefaults(t *testing.T) {
+	p := NewParser("testAddPCopiesDefaults")
+
+	// spare capacity would let appends write into the caller's array
+	defaultTags := make([]string, 1, 10)
+	defaultTags[0] = "a"
+	defaultLabels := map[string]string{"env": "dev"}
+	tags := AddP[[]string](&p.Subcommand, "t", "tag", defaultTags, "tag flag")
+	labels := AddP(&p.Subcommand, "l", "label", defaultLabels, "label flag")
+	err := p.ParseArgs([]string{"-t", "b", "-l", "env=prod,team=infra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*tags) != 2 || len(*labels) != 2 || (*labels)["env"] != "prod" {
+		t.Fatal("flags incorrect", *tags, *labels)
+	}
+	if defaultTags[:cap(defaultTags)][1] != "" || len(defaultLabels) != 1 || defaultLabels["env"] != "dev" {
+		t.Fatal("parsing changed the caller's defaults", defaultTags[:2], defaultLabels)
+	}
+}
+
+// checkGet adds a flag with the supplied constructor and checks that Get
+// returns its value as the type the constructor accepts
+func checkGet[T any](t *testing.T, sc *Subcommand, name string, add func(*Subcommand, *T, string, string, string)) {
+	var v T
+	add(sc, &v, "", name, name+" flag")
+	got, err := Get[T](sc, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Fatal("unexpected value of", name, got)
+	}
+}
+
+func TestGetBuiltInTypes(t *testing.T) {
+	sc := NewSubcommand("testGetBuiltInTypes")
+	checkGet(t, sc, "string", (*Subcommand).String)
+	checkGet(t, sc, "string-slice", (*Subcommand).StringSlice)
+	checkGet(t, sc, "bool", (*Subcommand).Bool)
+	checkGet(t, sc, "bool-slice", (*Subcommand).BoolSlice)
+	checkGet(t, sc, "byte-slice", (*Subcommand).ByteSlice)
+	checkGet(t, sc, "byte-size", (*Subcommand).ByteSize)
+	checkGet(t, sc, "byte-size-slice", (*Subcommand).ByteSizeSlice)
+	checkGet(t, sc, "percentage", (*Subcommand).Percentage)
+	checkGet(t, sc, "percentage-slice", (*Subcommand).PercentageSlice)
+	checkGet(t, sc, "string-map", (*Subcommand).StringMap)
+	checkGet(t, sc, "int-map", (*Subcommand).IntMap)
+	checkGet(t, sc, "duration-map", (*Subcommand).DurationMap)
+	checkGet(t, sc, "file", (*Subcommand).File)
+	checkGet(t, sc, "existing-file", (*Subcommand).ExistingFile)
+	checkGet(t, sc, "existing-dir", (*Subcommand).ExistingDir)
+	checkGet(t, sc, "output-file", (*Subcommand).OutputFile)
+	checkGet(t, sc, "hex-bytes", (*Subcommand).HexBytes)
+	checkGet(t, sc, "base64-bytes", (*Subcommand).Base64Bytes)
+	checkGet(t, sc, "bytes-from-file", (*Subcommand).BytesFromFile)
+	checkGet(t, sc, "duration", (*Subcommand).Duration)
+	checkGet(t, sc, "duration-slice", (*Subcommand).DurationSlice)
+	checkGet(t, sc, "float32", (*Subcommand).Float32)
+	checkGet(t, sc, "float32-slice", (*Subcommand).Float32Slice)
+	checkGet(t, sc, "float64", (*Subcommand).Float64)
+	checkGet(t, sc, "float64-slice", (*Subcommand).Float64Slice)
+	checkGet(t, sc, "int", (*Subcommand).Int)
+	checkGet(t, sc, "int-slice", (*Subcommand).IntSlice)
+	checkGet(t, sc, "uint", (*Subcommand).UInt)
+	checkGet(t, sc, "uint-slice", (*Subcommand).UIntSlice)
+	checkGet(t, sc, "uint64", (*Subcommand).UInt64)
+	checkGet(t, sc, "uint64-slice", (*Subcommand).UInt64Slice)
+	checkGet(t, sc, "uint32", (*Subcommand).UInt32)
+	checkGet(t, sc, "uint32-slice", (*Subcommand).UInt32Slice)
+	checkGet(t, sc, "uint16", (*Subcommand).UInt16)
+	checkGet(t, sc, "uint16-slice", (*Subcommand).UInt16Slice)
+	checkGet(t, sc, "uint8", (*Subcommand).UInt8)
+	checkGet(t, sc, "uint8-slice", (*Subcommand).UInt8Slice)
+	checkGet(t, sc, "int64", (*Subcommand).Int64)
+	checkGet(t, sc, "int64-slice", (*Subcommand).Int64Slice)
+	checkGet(t, sc, "int32", (*Subcommand).Int32)
+	checkGet(t, sc, "int32-slice", (*Subcommand).Int32Slice)
+	checkGet(t, sc, "int16", (*Subcommand).Int16)
+	checkGet(t, sc, "int16-slice", (*Subcommand).Int16Slice)
+	checkGet(t, sc, "int8", (*Subcommand).Int8)
+	checkGet(t, sc, "int8-slice", (*Subcommand).Int8Slice)
+	checkGet(t, sc, "ip", (*Subcommand).IP)
+	checkGet(t, sc, "ip-slice", (*Subcommand).IPSlice)
+	checkGet(t, sc, "hardware-addr", (*Subcommand).HardwareAddr)
+	checkGet(t, sc, "hardware-addr-slice", (*Subcommand).HardwareAddrSlice)
+	checkGet(t, sc, "ip-mask", (*Subcommand).IPMask)
+	checkGet(t, sc, "ip-mask-slice", (*Subcommand).IPMaskSlice)
+	checkGet(t, sc, "ip-net", (*Subcommand).IPNet)
+	checkGet(t, sc, "ip-net-slice", (*Subcommand).IPNetSlice)
+	checkGet(t, sc, "tcp-addr", (*Subcommand).TCPAddr)
+	checkGet(t, sc, "tcp-addr-slice", (*Subcommand).TCPAddrSlice)
+	checkGet(t, sc, "addr", (*Subcommand).Addr)
+	checkGet(t, sc, "addr-slice", (*Subcommand).AddrSlice)
+	checkGet(t, sc, "prefix", (*Subcommand).Prefix)
+	checkGet(t, sc, "prefix-slice", (*Subcommand).PrefixSlice)
+	checkGet(t, sc, "url", (*Subcommand).URL)
+	checkGet(t, sc, "url-slice", (*Subcommand).URLSlice)
+
+	// time flags also take a layout
+	var when time.Time
+	sc.Time(&when, "", "", "time", "time flag")
+	if _, err := Get[time.Time](sc, "time"); err != nil {
+		t.Fatal(err)
+	}
+	var whens []time.Time
+	sc.TimeSlice(&whens, "", "", "time-slice", "time slice flag")
+	if _, err := Get[[]time.Time](sc, "time-slice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get[string](sc, "hex-bytes"); err == nil {
+		t.Fatal("expected an error getting hex bytes as a string")
+	}
+}
@@ -0,0 +1,83 @@
+package flaggy
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fileValuePrefix marks a flag value that should be loaded from a file, or
+// from stdin when followed by fileValueStdin
+const fileValuePrefix = "@"
+const fileValueStdin = "-"
+
+// DefaultMaxFileValueSize is the largest file, in bytes, that will be loaded
+// as a flag value when the parser does not specify its own limit
+const DefaultMaxFileValueSize = 1024 * 1024
+
+// loadFileValue returns the value to assign to the specified flag, which was
+// supplied with the name key.  When the parser or flag allows file values and the value begins with
+// @, the contents of the referenced file (or stdin for @-) are returned with
+// any trailing newline removed.  A value beginning with @@ is returned with
+// the first @ removed so that literal @ values can still be passed.  Values
+// are remembered in the loaded map, which belongs to a single parse.
+func (p *Parser) loadFileValue(f *Flag, key string, value string, loaded map[string]string) (string, error) {
+	if !strings.HasPrefix(value, fileValuePrefix) || !p.allowsFileValue(f) {
+		return value, nil
+	}
+	reference := strings.TrimPrefix(value, fileValuePrefix)
+
+	// an escaped @ is a literal value
+	if strings.HasPrefix(reference, fileValuePrefix) {
+		return reference, nil
+	}
+
+	// remember what was loaded so that a reference used more than once does
+	// not read files (and especially stdin) again
+	if previous, ok := loaded[reference]; ok {
+		return previous, nil
+	}
+
+	var r io.Reader
+	if reference == fileValueStdin {
+		// concurrent calls to ParseArgsInto share the input, so only one
+		// reads it at a time
+		p.inputLock.Lock()
+		defer p.inputLock.Unlock()
+		r = p.inputReader()
+	} else {
+		file, err := os.Open(reference)
+		if err != nil {
+			return "", errors.New("Unable to read value for flag " + key + ": " + err.Error())
+		}
+		defer file.Close()
+		r = file
+	}
+
+	maxSize := p.MaxFileValueSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxFileValueSize
+	}
+	contents, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return "", errors.New("Unable to read value for flag " + key + ": " + err.Error())
+	}
+	if int64(len(contents)) > maxSize {
+		return "", errors.New("Value for flag " + key + " is larger than the maximum of " + strconv.FormatInt(maxSize, 10) + " bytes")
+	}
+
+	contentsValue := strings.TrimSuffix(string(contents), "\n")
+	contentsValue = strings.TrimSuffix(contentsValue, "\r")
+	loaded[reference] = contentsValue
+	return contentsValue, nil
+}
+
+// allowsFileValue determines if the specified flag may have its value loaded
+// from a file, either because the parser allows it for all flags or because
+// the flag itself allows it
+func (p *Parser) allowsFileValue(f *Flag) bool {
+	return p.AllowFileValues || f.AllowFileValue
+}
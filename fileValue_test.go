@@ -0,0 +1,72 @@
+package flaggy
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileValues(t *testing.T) {
+	f, err := ioutil.TempFile("", "flaggy-file-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("secret token\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var token string
+	var body string
+	var literal string
+	var plain string
+	p := NewParser("testFileValues")
+	sc := NewSubcommand("sc")
+	p.AttachSubcommand(sc, 1)
+	sc.String(&token, "t", "token", "token flag")
+	sc.String(&body, "b", "body", "body flag")
+	sc.String(&literal, "l", "literal", "literal flag")
+	p.String(&plain, "p", "plain", "plain flag")
+	for _, f := range sc.Flags {
+		f.AllowFileValue = true
+	}
+	p.SetInput(strings.NewReader("body from stdin\n"))
+
+	err = p.ParseArgs([]string{"sc", "--token=@" + f.Name(), "-b", "@-", "-l", "@@literal", "-p", "@plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret token" {
+		t.Fatal("token was not loaded from file:", token)
+	}
+	if body != "body from stdin" {
+		t.Fatal("body was not loaded from stdin:", body)
+	}
+	if literal != "@literal" {
+		t.Fatal("escaped @ value was not passed literally:", literal)
+	}
+	if plain != "@plain" {
+		t.Fatal("flag without file values enabled was loaded from a file:", plain)
+	}
+
+	// the references are remembered as raw values rather than the contents
+	if sc.lookupFlag("token").RawValue() != "@"+f.Name() || sc.lookupFlag("body").RawValue() != "@-" {
+		t.Fatal("raw values exposed loaded contents:", sc.lookupFlag("token").RawValue(), sc.lookupFlag("body").RawValue())
+	}
+}
+
+func TestFileValueSizeLimit(t *testing.T) {
+	var body string
+	p := NewParser("testFileValueSizeLimit")
+	p.String(&body, "b", "body", "body flag")
+	p.AllowFileValues = true
+	p.MaxFileValueSize = 4
+	p.SetInput(strings.NewReader("too large"))
+	err := p.ParseArgs([]string{"-b", "@-"})
+	if err == nil {
+		t.Fatal("Expected an error when the file value exceeds the size limit")
+	}
+}
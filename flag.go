@@ -1,10 +1,16 @@
 package flaggy
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,16 +18,106 @@ import (
 
 // Flag holds the base methods for all flag types
 type Flag struct {
-	ShortName     string
-	LongName      string
-	Description   string
-	rawValue      string // the value as a string before being parsed
-	Hidden        bool   // indicates this flag should be hidden from help and suggestions
-	AssignmentVar interface{}
-	defaultValue  string // the value (as a string), that was set by default before any parsing and assignment
-	parsed        bool   // indicates that this flag has already been parsed
+	ShortName      string
+	LongName       string
+	Description    string
+	rawValue       string             // the value as a string before being parsed
+	Hidden         bool               // indicates this flag should be hidden from help and suggestions
+	Group          string             // the help section this flag is listed under.  Blank for the default Flags section
+	ValueName      string             // the placeholder for this flag's value in help, such as PORT.  Defaults to the value's type
+	AbsolutePath   bool               // resolve the values of path flags to absolute paths
+	AllowFileValue bool               // allow this flag's value to be loaded from a file with @path or stdin with @-
+	DuplicateKeys  DuplicateKeyPolicy // what happens when a map flag is given the same key twice
+	Separator      string             // splits values of slice and map flags.  Defaults to a comma
+	DisableSplit   bool               // do not split values of slice and map flags
+	QuotedSplit    bool               // allow separators in values of slice and map flags to be escaped (a\,b) or quoted ("a,b")
+	SliceDefaults  SliceDefaultPolicy // how supplied values combine with a slice flag's defaults
+	Scope          FlagScope          // which subcommands this flag can be used with.  See ScopeDefault
+	Prompt         *Prompt            // asks for this flag's value on a terminal when it is not supplied to ParseContext
+	AssignmentVar  interface{}
+	defaultValue   string          // the value (as a string), that was set by default before any parsing and assignment
+	parsed         bool            // indicates that this flag has already been parsed
+	mapKeysSet     map[string]bool // the map keys set while parsing
+	replaceDefault bool            // indicates the slice default should be replaced on first use
+	defaultCleared bool            // indicates the slice default has already been replaced
+	timeLayout     string          // the layout used to parse and display time flags
+	source         ValueSource     // where the current value of this flag came from
 }
 
+// SliceDefaultPolicy determines how values supplied to a slice flag combine
+// with the values already in its AssignmentVar
+type SliceDefaultPolicy int
+
+// The available policies for slice flag defaults
+const (
+	SliceDefaultInherit SliceDefaultPolicy = iota // use the policy of the parser, which appends by default
+	SliceDefaultAppend                            // supplied values are appended to the defaults
+	SliceDefaultReplace                           // the first supplied value replaces the defaults
+)
+
+// ValueSource describes where the current value of a flag came from
+type ValueSource int
+
+// The available sources of flag values
+const (
+	SourceDefault ValueSource = iota // the value has not been changed from its default
+	SourceArgs                       // the value was supplied on the command line
+	SourcePrompt                     // the value was entered at a prompt
+	SourceEnv                        // the value was loaded from an environment variable
+	SourceConfig                     // the value was loaded from a configuration file or set by the program
+)
+
+// String returns the name of the value source
+func (s ValueSource) String() string {
+	switch s {
+	case SourceArgs:
+		return "argv"
+	case SourcePrompt:
+		return "prompt"
+	case SourceEnv:
+		return "env"
+	case SourceConfig:
+		return "config"
+	}
+	return "default"
+}
+
+// defaultSeparator splits values of slice and map flags when the flag does
+// not specify a Separator
+const defaultSeparator = ","
+
+// DuplicateKeyPolicy determines what happens when a map flag is given the
+// same key more than once while parsing
+type DuplicateKeyPolicy int
+
+// The available policies for duplicate map flag keys.  Keys that were in the
+// map before parsing are always replaced.
+const (
+	DuplicateKeyOverwrite DuplicateKeyPolicy = iota // the last value supplied for a key is used
+	DuplicateKeyKeepFirst                           // the first value supplied for a key is used
+	DuplicateKeyError                               // supplying a key with a different value twice is an error
+)
+
+// hexBytes, base64Bytes and fileBytes distinguish the encodings accepted by
+// byte flags, since a plain []byte can not be told apart from a []uint8
+type hexBytes []byte
+type base64Bytes []byte
+type fileBytes []byte
+
+// byteSize and percentage distinguish values that are parsed with units from
+// plain numbers
+type byteSize uint64
+type byteSizeSlice []uint64
+type percentage float64
+type percentageSlice []float64
+
+// filePath, existingFilePath, existingDirPath and outputFilePath distinguish
+// the validation performed on path flags, which are otherwise plain strings
+type filePath string
+type existingFilePath string
+type existingDirPath string
+type outputFilePath string
+
 // HasName indicates that this flag's short or long name matches the
 // supplied name string
 func (f *Flag) HasName(name string) bool {
@@ -32,10 +128,81 @@ func (f *Flag) HasName(name string) bool {
 	return false
 }
 
+// Source reports where the current value of this flag came from
+func (f *Flag) Source() ValueSource {
+	return f.source
+}
+
+// Changed determines if this flag's value was set by anything other than its
+// default
+func (f *Flag) Changed() bool {
+	return f.source != SourceDefault
+}
+
+// RawValue returns the last value supplied for this flag before it was parsed
+// into the flag's type.  The value is blank when the flag was not supplied.
+func (f *Flag) RawValue() string {
+	return f.rawValue
+}
+
+// DefaultValue returns this flag's default value formatted as it is
+// displayed in help output
+func (f *Flag) DefaultValue() string {
+	if !f.parsed {
+		f.recordDefault()
+	}
+	return f.defaultValue
+}
+
+// valuePlaceholder returns the placeholder displayed after this flag's name in
+// help output.  The ValueName is used when set, otherwise a hint is derived
+// from the type of the AssignmentVar.
+func (f *Flag) valuePlaceholder() string {
+	if f.ValueName != "" {
+		return f.ValueName
+	}
+	return f.typeHint()
+}
+
+// helpName returns the long name of this flag followed by its value
+// placeholder, as it is displayed in help output
+func (f *Flag) helpName() string {
+	placeholder := f.valuePlaceholder()
+	if placeholder == "" {
+		return f.LongName
+	}
+	return f.LongName + " " + placeholder
+}
+
+// typeHint returns a short description of the type of value this flag
+// accepts for help output.  Bools return a blank hint because they do not
+// require a value.
+func (f *Flag) typeHint() string {
+	if c, ok := lookupConverter(f.AssignmentVar); ok {
+		return c.hint()
+	}
+	return ""
+}
+
+// recordDefault remembers the current value of the AssignmentVar as the
+// default displayed in help output
+func (f *Flag) recordDefault() {
+	f.parsed = true
+	f.defaultValue, _ = f.returnAssignmentVarValueAsString()
+}
+
 // identifyAndAssignValue identifies the type of the incoming value
 // and assigns it to the AssignmentVar pointer's target value.  If
 // the value is a type that needs parsing, that is performed as well.
 func (f *Flag) identifyAndAssignValue(value string) error {
+	return f.assignSuppliedValue(value, value)
+}
+
+// assignSuppliedValue assigns value like identifyAndAssignValue, but
+// remembers raw as the value that was supplied.  They differ when the value
+// was loaded from a file with @path or @-, so that the contents loaded, which
+// are often secrets, are not exposed by RawValue or debug output.
+func (f *Flag) assignSuppliedValue(raw string, value string) error {
 
 	var err error
 
@@ -50,288 +217,469 @@ func (f *Flag) identifyAndAssignValue(value string) error {
 		}
 	}
 
-	debugPrint("attempting to assign value", value, "to flag", f.LongName)
-	f.rawValue = value // remember the raw value
+	debugPrint("attempting to assign value", raw, "to flag", f.LongName)
+	f.rawValue = raw // remember the raw value
 
-	// depending on the type of the assignment variable, we convert the
-	// incoming string and assign it.  We only use pointers to variables
-	// in flagy.  No returning vars by value.
-	switch f.AssignmentVar.(type) {
-	case *string:
-		v, _ := (f.AssignmentVar).(*string)
-		*v = value
-	case *[]string:
-		v := f.AssignmentVar.(*[]string)
-		splitString := strings.Split(value, ",")
-		new := append(*v, splitString...)
-		*v = new
-	case *bool:
-		v, err := strconv.ParseBool(value)
-		if err != nil {
-			return err
-		}
-		a, _ := (f.AssignmentVar).(*bool)
-		*a = v
-	case *[]bool:
-		// parse the incoming bool
-		b, err := strconv.ParseBool(value)
-		if err != nil {
-			return err
-		}
-		// cast the assignment var
-		existing := f.AssignmentVar.(*[]bool)
-		// deref the assignment var and append to it
-		v := append(*existing, b)
-		// pointer the new value and assign it
-		a, _ := (f.AssignmentVar).(*[]bool)
-		*a = v
-	case *time.Duration:
-		v, err := time.ParseDuration(value)
+	// slice flags accept several separated values at once
+	values := []string{value}
+	if f.isSliceFlag() {
+		values, err = f.splitValue(value)
 		if err != nil {
 			return err
 		}
-		a, _ := (f.AssignmentVar).(*time.Duration)
-		*a = v
-	case *[]time.Duration:
-		t, err := time.ParseDuration(value)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]time.Duration)
-		// deref the assignment var and append to it
-		v := append(*existing, t)
-		// pointer the new value and assign it
-		a, _ := (f.AssignmentVar).(*[]time.Duration)
-		*a = v
-	case *float32:
-		v, err := strconv.ParseFloat(value, 32)
-		if err != nil {
-			return err
-		}
-		float := float32(v)
-		a, _ := (f.AssignmentVar).(*float32)
-		*a = float
-	case *[]float32:
-		v, err := strconv.ParseFloat(value, 32)
-		if err != nil {
-			return err
-		}
-		float := float32(v)
-		existing := f.AssignmentVar.(*[]float32)
-		new := append(*existing, float)
-		*existing = new
-	case *float64:
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return err
-		}
-		a, _ := (f.AssignmentVar).(*float64)
-		*a = v
-	case *[]float64:
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]float64)
-		new := append(*existing, v)
 
-		*existing = new
-	case *int:
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return err
-		}
-		e := f.AssignmentVar.(*int)
-		*e = v
-	case *[]int:
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]int)
-		new := append(*existing, v)
-		*existing = new
-	case *uint:
-		v, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*uint)
-		*existing = uint(v)
-	case *[]uint:
-		v, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]uint)
-		new := append(*existing, uint(v))
-		*existing = new
-	case *uint64:
-		v, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*uint64)
-		*existing = v
-	case *[]uint64:
-		v, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]uint64)
-		new := append(*existing, v)
-		*existing = new
-	case *uint32:
-		v, err := strconv.ParseUint(value, 10, 32)
-		if err != nil {
-			return err
+		// the first value supplied replaces the default when requested.  The
+		// default was remembered above so help output still displays it.
+		if f.replaceDefault && !f.defaultCleared {
+			f.defaultCleared = true
+			slice := reflect.ValueOf(f.AssignmentVar).Elem()
+			slice.Set(reflect.Zero(slice.Type()))
 		}
-		existing := f.AssignmentVar.(*uint32)
-		*existing = uint32(v)
-	case *[]uint32:
-		v, err := strconv.ParseUint(value, 10, 32)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]uint32)
-		new := append(*existing, uint32(v))
-		*existing = new
-	case *uint16:
-		v, err := strconv.ParseUint(value, 10, 16)
-		if err != nil {
-			return err
-		}
-		val := uint16(v)
-		existing := f.AssignmentVar.(*uint16)
-		*existing = val
-	case *[]uint16:
-		v, err := strconv.ParseUint(value, 10, 16)
-		if err != nil {
-			return err
-		}
-		existing := f.AssignmentVar.(*[]uint16)
-		new := append(*existing, uint16(v))
-		*existing = new
-	case *uint8:
-		v, err := strconv.ParseUint(value, 10, 8)
-		if err != nil {
-			return err
-		}
-		val := uint8(v)
-		existing := f.AssignmentVar.(*uint8)
-		*existing = val
-	case *[]uint8:
-		var newSlice []uint8
+	}
 
-		v, err := strconv.ParseUint(value, 10, 8)
-		if err != nil {
-			return err
-		}
-		newV := uint8(v)
-		existing := f.AssignmentVar.(*[]uint8)
-		newSlice = append(*existing, newV)
-		*existing = newSlice
-	case *int64:
-		v, err := strconv.ParseInt(value, 10, 64)
+	for _, v := range values {
+		err = f.assignValue(v)
 		if err != nil {
 			return err
 		}
-		existing := f.AssignmentVar.(*int64)
-		*existing = v
-	case *[]int64:
-		v, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return err
-		}
-		existingSlice := f.AssignmentVar.(*[]int64)
-		newSlice := append(*existingSlice, v)
-		*existingSlice = newSlice
-	case *int32:
-		v, err := strconv.ParseInt(value, 10, 32)
-		if err != nil {
-			return err
+	}
+
+	return nil
+}
+
+// assignValue converts a single incoming value to the type of the
+// AssignmentVar and assigns it.  Slice types have the value appended.
+func (f *Flag) assignValue(value string) error {
+
+	// every supported type, including those flaggy supports natively, has a
+	// registered converter.  We only use pointers to variables in flagy.  No
+	// returning vars by value.
+	c, ok := lookupConverter(f.AssignmentVar)
+	if !ok {
+		return errors.New("Unknown flag assignmentVar supplied in flag " + f.LongName + " " + f.ShortName)
+	}
+	return c.assign(f, value)
+}
+
+// applySliceDefaultPolicy determines if this flag should replace its default
+// on first use based on its own policy, falling back to the supplied policy
+// of its parser
+func (f *Flag) applySliceDefaultPolicy(parserPolicy SliceDefaultPolicy) {
+	policy := f.SliceDefaults
+	if policy == SliceDefaultInherit {
+		policy = parserPolicy
+	}
+	f.replaceDefault = policy == SliceDefaultReplace
+}
+
+// isSliceFlag determines if this flag's AssignmentVar is a slice that
+// collects several values
+func (f *Flag) isSliceFlag() bool {
+	c, ok := lookupConverter(f.AssignmentVar)
+	return ok && c.isSlice()
+}
+
+// splitValue splits a value for a slice or map flag on the flag's
+// Separator, which defaults to a comma.  Nothing is split when DisableSplit is
+// set.  Separators can only be escaped or quoted when QuotedSplit is set.  See
+// splitSeparatedValue for quoting and escaping rules.
+func (f *Flag) splitValue(value string) ([]string, error) {
+	if f.DisableSplit {
+		return []string{value}, nil
+	}
+	separator := f.Separator
+	if separator == "" {
+		separator = defaultSeparator
+	}
+	if !f.QuotedSplit {
+		return strings.Split(value, separator), nil
+	}
+	values, err := splitSeparatedValue(value, separator)
+	if err != nil {
+		return nil, errors.New("Invalid value " + value + " supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
+	}
+	return values, nil
+}
+
+// splitSeparatedValue splits a value on the supplied separator.  A literal
+// separator can be included by escaping it with a backslash (a\,b) or by
+// quoting the whole field CSV style ("a,b").  Within quotes, a doubled quote
+// ("") is a literal quote.  A backslash before anything other than the
+// separator, a quote, or another backslash is kept as is.
+func splitSeparatedValue(value string, separator string) ([]string, error) {
+	var values []string
+	var current strings.Builder
+	fieldStart := true
+	i := 0
+	for i < len(value) {
+
+		// quoted fields run until their closing quote
+		if fieldStart && value[i] == '"' {
+			i++
+			var closed bool
+			for i < len(value) {
+				if value[i] == '"' {
+					if i+1 < len(value) && value[i+1] == '"' {
+						current.WriteByte('"')
+						i += 2
+						continue
+					}
+					i++
+					closed = true
+					break
+				}
+				current.WriteByte(value[i])
+				i++
+			}
+			if !closed {
+				return nil, errors.New("unterminated quote")
+			}
+			if i < len(value) && !strings.HasPrefix(value[i:], separator) {
+				return nil, errors.New("unexpected characters after quoted value")
+			}
+			fieldStart = false
+			continue
+		}
+
+		// escaped separators, quotes and backslashes are literal
+		if value[i] == '\\' && i+1 < len(value) {
+			rest := value[i+1:]
+			if strings.HasPrefix(rest, separator) {
+				current.WriteString(separator)
+				i += 1 + len(separator)
+				fieldStart = false
+				continue
+			}
+			if rest[0] == '\\' || rest[0] == '"' {
+				current.WriteByte(rest[0])
+				i += 2
+				fieldStart = false
+				continue
+			}
 		}
-		converted := int32(v)
-		existing := f.AssignmentVar.(*int32)
-		*existing = converted
-	case *[]int32:
-		v, err := strconv.ParseInt(value, 10, 32)
-		if err != nil {
-			return err
+
+		if strings.HasPrefix(value[i:], separator) {
+			values = append(values, current.String())
+			current.Reset()
+			i += len(separator)
+			fieldStart = true
+			continue
 		}
-		existingSlice := f.AssignmentVar.(*[]int32)
-		newSlice := append(*existingSlice, int32(v))
-		*existingSlice = newSlice
-	case *int16:
-		v, err := strconv.ParseInt(value, 10, 16)
-		if err != nil {
-			return err
+
+		current.WriteByte(value[i])
+		i++
+		fieldStart = false
+	}
+
+	return append(values, current.String()), nil
+}
+
+// parseMapEntries parses a value of comma separated key=value pairs into a
+// list of keys and values, applying the flag's DuplicateKeys policy.  Entries
+// that should not be assigned because of the policy are left out.
+func (f *Flag) parseMapEntries(value string) ([][2]string, error) {
+	if f.mapKeysSet == nil {
+		f.mapKeysSet = make(map[string]bool)
+	}
+
+	pairs, err := f.splitValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries [][2]string
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.New("Invalid key=value pair " + pair + " supplied for flag " + f.LongName + " " + f.ShortName)
 		}
-		converted := int16(v)
-		existing := f.AssignmentVar.(*int16)
-		*existing = converted
-	case *[]int16:
-		v, err := strconv.ParseInt(value, 10, 16)
-		if err != nil {
-			return err
+		key, val := kv[0], kv[1]
+
+		if f.mapKeysSet[key] {
+			switch f.DuplicateKeys {
+			case DuplicateKeyKeepFirst:
+				continue
+			case DuplicateKeyError:
+				return nil, errors.New("Key " + key + " supplied more than once for flag " + f.LongName + " " + f.ShortName)
+			}
 		}
-		existingSlice := f.AssignmentVar.(*[]int16)
-		newSlice := append(*existingSlice, int16(v))
-		*existingSlice = newSlice
-	case *int8:
-		v, err := strconv.ParseInt(value, 10, 8)
-		if err != nil {
-			return err
+
+		f.mapKeysSet[key] = true
+		entries = append(entries, [2]string{key, val})
+	}
+	return entries, nil
+}
+
+// sortedMapKeys returns the keys of the supplied map in sorted order so that
+// maps display consistently in help output
+func sortedMapKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// layout returns the layout used to parse and display time flags.  Defaults
+// to RFC3339.
+func (f *Flag) layout() string {
+	if f.timeLayout == "" {
+		return time.RFC3339
+	}
+	return f.timeLayout
+}
+
+// parseTime parses a time in the supplied layout.  The value "now" and values
+// relative to now, such as -2h or +30m, are also accepted.
+func parseTime(value string, layout string) (time.Time, error) {
+	if value == "now" {
+		return time.Now(), nil
+	}
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			return time.Now().Add(d), nil
 		}
-		converted := int8(v)
-		existing := f.AssignmentVar.(*int8)
-		*existing = converted
-	case *[]int8:
-		v, err := strconv.ParseInt(value, 10, 8)
-		if err != nil {
-			return err
+	}
+	return time.Parse(layout, value)
+}
+
+// byteSizeUnits are the units accepted by byte size flags, by their lower
+// case suffix
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"pb":  1000 * 1000 * 1000 * 1000 * 1000,
+	"eb":  1000 * 1000 * 1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+	"eib": 1 << 60,
+}
+
+// byteSizeDisplayUnits are the units used to display byte sizes, from
+// largest to smallest
+var byteSizeDisplayUnits = []string{"EiB", "PiB", "TiB", "GiB", "MiB", "KiB", "EB", "PB", "TB", "GB", "MB", "KB"}
+
+// parseByteSize parses a size such as 512, 10MiB or 1.5GB into a number of
+// bytes.  Units are case insensitive.  KB, MB and friends are powers of 1000
+// while KiB, MiB and friends are powers of 1024.
+func parseByteSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	number, unit := value[:i], strings.ToLower(strings.TrimSpace(value[i:]))
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, errors.New("Unknown byte size unit in " + value)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, errors.New("Invalid byte size " + value)
+	}
+	size := n * float64(multiplier)
+	if size >= math.MaxUint64 {
+		return 0, errors.New("Byte size " + value + " is too large")
+	}
+	return uint64(size), nil
+}
+
+// formatByteSize formats a number of bytes with the largest unit of 1024 that
+// divides it evenly, or else with the largest unit of 1000 that it is at
+// least one of
+func formatByteSize(size uint64) string {
+	for _, unit := range byteSizeDisplayUnits[:6] {
+		multiplier := byteSizeUnits[strings.ToLower(unit)]
+		if size >= multiplier && size%multiplier == 0 {
+			return strconv.FormatUint(size/multiplier, 10) + unit
 		}
-		existingSlice := f.AssignmentVar.(*[]int8)
-		newSlice := append(*existingSlice, int8(v))
-		*existingSlice = newSlice
-	case *net.IP:
-		v := net.ParseIP(value)
-		existing := f.AssignmentVar.(*net.IP)
-		*existing = v
-	case *[]net.IP:
-		v := net.ParseIP(value)
-		existing := f.AssignmentVar.(*[]net.IP)
-		new := append(*existing, v)
-		*existing = new
-	case *net.HardwareAddr:
-		v, err := net.ParseMAC(value)
-		if err != nil {
-			return err
+	}
+	for _, unit := range byteSizeDisplayUnits[6:] {
+		multiplier := byteSizeUnits[strings.ToLower(unit)]
+		if size >= multiplier {
+			return strconv.FormatFloat(float64(size)/float64(multiplier), 'f', -1, 64) + unit
 		}
-		existing := f.AssignmentVar.(*net.HardwareAddr)
-		*existing = v
-	case *[]net.HardwareAddr:
-		v, err := net.ParseMAC(value)
+	}
+	return strconv.FormatUint(size, 10) + "B"
+}
+
+// parsePercentage parses a percentage such as 50% or 12.5 into a fraction
+// such as 0.5 or 0.125
+func parsePercentage(value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, errors.New("Invalid percentage " + value)
+	}
+	return v / 100, nil
+}
+
+// formatPercentage formats a fraction such as 0.5 as a percentage such as 50%
+func formatPercentage(fraction float64) string {
+	return strconv.FormatFloat(fraction*100, 'f', -1, 64) + "%"
+}
+
+// parseIP parses an IPv4 or IPv6 address
+func parseIP(value string) (net.IP, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, errors.New("Invalid IP address " + value)
+	}
+	return ip, nil
+}
+
+// parseIPMask parses an IPv4 mask such as 255.255.255.0 or an IPv6 mask such
+// as ffff:ffff:ffff:ffff::.  Masks must be contiguous ones followed by zeros.
+func parseIPMask(value string) (net.IPMask, error) {
+	ip, err := parseIP(value)
+	if err != nil {
+		return nil, errors.New("Invalid IP mask " + value)
+	}
+	mask := net.IPMask(ip.To16())
+	if ip4 := ip.To4(); ip4 != nil && !strings.Contains(value, ":") {
+		mask = net.IPMask(ip4)
+	}
+	if _, bits := mask.Size(); bits == 0 {
+		return nil, errors.New("Invalid IP mask " + value + ".  Masks must be contiguous.")
+	}
+	return mask, nil
+}
+
+// parseTCPAddr parses an address such as 127.0.0.1:8080, [::1]:443 or :80.
+// The host must be an IP address and the port a number, so that parsing
+// never looks up host names or services.
+func parseTCPAddr(value string) (net.TCPAddr, error) {
+	host, portValue, err := net.SplitHostPort(value)
+	if err != nil {
+		return net.TCPAddr{}, err
+	}
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil {
+		return net.TCPAddr{}, errors.New("Invalid port in address " + value)
+	}
+	addr := net.TCPAddr{Port: int(port)}
+	if host == "" {
+		return addr, nil
+	}
+	host, addr.Zone, _ = strings.Cut(host, "%")
+	addr.IP = net.ParseIP(host)
+	if addr.IP == nil {
+		return net.TCPAddr{}, errors.New("Invalid IP address in address " + value + ".  Host names are not supported.")
+	}
+	return addr, nil
+}
+
+// parseURL parses an absolute URL such as https://example.com/path
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, errors.New("URL " + value + " is not absolute")
+	}
+	return u, nil
+}
+
+// expandPath expands environment variables and a leading ~ in the supplied
+// path, then resolves it to an absolute path if the flag requests it
+func (f *Flag) expandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
 		if err != nil {
-			return err
+			return "", errors.New("Unable to expand ~ in path supplied for flag " + f.LongName + " " + f.ShortName + ": " + err.Error())
 		}
-		existing := f.AssignmentVar.(*[]net.HardwareAddr)
-		new := append(*existing, v)
-		*existing = new
-	case *net.IPMask:
-		v := net.IPMask(net.ParseIP(value).To4())
-		existing := f.AssignmentVar.(*net.IPMask)
-		*existing = v
-	case *[]net.IPMask:
-		v := net.IPMask(net.ParseIP(value).To4())
-		existing := f.AssignmentVar.(*[]net.IPMask)
-		new := append(*existing, v)
-		*existing = new
-	default:
-		return errors.New("Unknown flag assignmentVar supplied in flag " + f.LongName + " " + f.ShortName)
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	if f.AbsolutePath {
+		return filepath.Abs(path)
+	}
+	return path, nil
+}
+
+// validateExistingFile ensures the supplied path is a readable regular file
+func validateExistingFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
 	}
+	if !fi.Mode().IsRegular() {
+		return errors.New(path + " is not a regular file")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// validateExistingDir ensures the supplied path is a readable directory
+func validateExistingDir(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return errors.New(path + " is not a directory")
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return dir.Close()
+}
 
-	return err
+// validateOutputFile ensures the supplied path is either a writable regular
+// file or does not exist yet within an existing directory.  Existing files
+// are not modified.
+func validateOutputFile(path string) error {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return validateExistingDir(filepath.Dir(path))
+	}
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return errors.New(path + " is not a regular file")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// decodeBase64 decodes standard or URL safe base64 input, with or without
+// padding
+func decodeBase64(value string) ([]byte, error) {
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+	var err error
+	for _, encoding := range encodings {
+		var v []byte
+		v, err = encoding.DecodeString(value)
+		if err == nil {
+			return v, nil
+		}
+	}
+	return nil, err
 }
 
 const argIsPositional = "positional"       // subcommand or positional value
@@ -418,16 +766,20 @@ func collectAllNestedFlags(sc *Subcommand) []*Flag {
 	return fullList
 }
 
-// flagIsBool determines if the flag is a bool within the specified parser
-// and subcommand's context
-func flagIsBool(sc *Subcommand, p *Parser, key string) bool {
-	for _, f := range append(collectAllNestedFlags(sc), p.Flags...) {
-		if f.HasName(key) {
-			_, isBool := f.AssignmentVar.(*bool)
-			_, isBoolSlice := f.AssignmentVar.(*[]bool)
-			if isBool || isBoolSlice {
-				return true
-			}
+// flagIsBool determines if the flag is a bool for the most specific
+// subcommand in the chain.  Flags that can not be resolved yet are checked
+// against the flags of every subcommand beneath it, since they may belong to
+// a subcommand that has not been found yet.
+func flagIsBool(chain []*Subcommand, key string) bool {
+	// the flag resolved for the subcommand being parsed decides when there is
+	// one, since a local flag of a parent may share its name
+	if _, f := resolveFlagInChain(chain, key); f != nil {
+		return f.isBoolFlag()
+	}
+
+	for _, f := range append(collectAllNestedFlags(chain[len(chain)-1]), chain[0].Flags...) {
+		if f.HasName(key) && f.isBoolFlag() {
+			return true
 		}
 	}
 
@@ -435,6 +787,16 @@ func flagIsBool(sc *Subcommand, p *Parser, key string) bool {
 	return false
 }
 
+// isBoolFlag determines if this flag is a bool or slice of bools, which do
+// not require a value
+func (f *Flag) isBoolFlag() bool {
+	switch f.AssignmentVar.(type) {
+	case *bool, *[]bool:
+		return true
+	}
+	return false
+}
+
 // returnAssignmentVarValueAsString returns the value of the flag's
 // assignment variable as a string.  This is used to display the
 // default value of flags before they are assigned (like when help is output).
@@ -442,192 +804,11 @@ func (f *Flag) returnAssignmentVarValueAsString() (string, error) {
 
 	debugPrint("returning current value of assignment var of flag", f.LongName)
 
-	var err error
-
-	// depending on the type of the assignment variable, we convert the
-	// incoming string and assign it.  We only use pointers to variables
-	// in flagy.  No returning vars by value.
-	switch f.AssignmentVar.(type) {
-	case *string:
-		v, _ := (f.AssignmentVar).(*string)
-		return *v, err
-	case *[]string:
-		v := f.AssignmentVar.(*[]string)
-		return strings.Join(*v, ","), err
-	case *bool:
-		a, _ := (f.AssignmentVar).(*bool)
-		return strconv.FormatBool(*a), err
-	case *[]bool:
-		value := f.AssignmentVar.(*[]bool)
-		var ss []string
-		for _, b := range *value {
-			ss = append(ss, strconv.FormatBool(b))
-		}
-		return strings.Join(ss, ","), err
-	case *time.Duration:
-		a := f.AssignmentVar.(*time.Duration)
-		return (*a).String(), err
-	case *[]time.Duration:
-		tds := f.AssignmentVar.(*[]time.Duration)
-		var asSlice []string
-		for _, td := range *tds {
-			asSlice = append(asSlice, td.String())
-		}
-		return strings.Join(asSlice, ","), err
-	case *float32:
-		a := f.AssignmentVar.(*float32)
-		return strconv.FormatFloat(float64(*a), 'f', 2, 32), err
-	case *[]float32:
-		v := f.AssignmentVar.(*[]float32)
-		var strSlice []string
-		for _, f := range *v {
-			formatted := strconv.FormatFloat(float64(f), 'f', 2, 32)
-			strSlice = append(strSlice, formatted)
-		}
-		return strings.Join(strSlice, ","), err
-	case *float64:
-		a := f.AssignmentVar.(*float64)
-		return strconv.FormatFloat(float64(*a), 'f', 2, 64), err
-	case *[]float64:
-		v := f.AssignmentVar.(*[]float64)
-		var strSlice []string
-		for _, f := range *v {
-			formatted := strconv.FormatFloat(float64(f), 'f', 2, 64)
-			strSlice = append(strSlice, formatted)
-		}
-		return strings.Join(strSlice, ","), err
-	case *int:
-		a := f.AssignmentVar.(*int)
-		return strconv.Itoa(*a), err
-	case *[]int:
-		val := f.AssignmentVar.(*[]int)
-		var strSlice []string
-		for _, i := range *val {
-			str := strconv.Itoa(i)
-			strSlice = append(strSlice, str)
-		}
-		return strings.Join(strSlice, ","), err
-	case *uint:
-		v := f.AssignmentVar.(*uint)
-		return strconv.FormatUint(uint64(*v), 10), err
-	case *[]uint:
-		values := f.AssignmentVar.(*[]uint)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatUint(uint64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *uint64:
-		v := f.AssignmentVar.(*uint64)
-		return strconv.FormatUint(*v, 10), err
-	case *[]uint64:
-		values := f.AssignmentVar.(*[]uint64)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatUint(i, 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *uint32:
-		v := f.AssignmentVar.(*uint32)
-		return strconv.FormatUint(uint64(*v), 10), err
-	case *[]uint32:
-		values := f.AssignmentVar.(*[]uint32)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatUint(uint64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *uint16:
-		v := f.AssignmentVar.(*uint16)
-		return strconv.FormatUint(uint64(*v), 10), err
-	case *[]uint16:
-		values := f.AssignmentVar.(*[]uint16)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatUint(uint64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *uint8:
-		v := f.AssignmentVar.(*uint8)
-		return strconv.FormatUint(uint64(*v), 10), err
-	case *[]uint8:
-		values := f.AssignmentVar.(*[]uint8)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatUint(uint64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *int64:
-		v := f.AssignmentVar.(*int64)
-		return strconv.FormatInt(int64(*v), 10), err
-	case *[]int64:
-		values := f.AssignmentVar.(*[]int64)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatInt(i, 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *int32:
-		v := f.AssignmentVar.(*int32)
-		return strconv.FormatInt(int64(*v), 10), err
-	case *[]int32:
-		values := f.AssignmentVar.(*[]int32)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatInt(int64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *int16:
-		v := f.AssignmentVar.(*int16)
-		return strconv.FormatInt(int64(*v), 10), err
-	case *[]int16:
-		values := f.AssignmentVar.(*[]int16)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatInt(int64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *int8:
-		v := f.AssignmentVar.(*int8)
-		return strconv.FormatInt(int64(*v), 10), err
-	case *[]int8:
-		values := f.AssignmentVar.(*[]int8)
-		var strVars []string
-		for _, i := range *values {
-			strVars = append(strVars, strconv.FormatInt(int64(i), 10))
-		}
-		return strings.Join(strVars, ","), err
-	case *net.IP:
-		val := f.AssignmentVar.(*net.IP)
-		return val.String(), err
-	case *[]net.IP:
-		val := f.AssignmentVar.(*[]net.IP)
-		var strSlice []string
-		for _, ip := range *val {
-			strSlice = append(strSlice, ip.String())
-		}
-		return strings.Join(strSlice, ","), err
-	case *net.HardwareAddr:
-		val := f.AssignmentVar.(*net.HardwareAddr)
-		return val.String(), err
-	case *[]net.HardwareAddr:
-		val := f.AssignmentVar.(*[]net.HardwareAddr)
-		var strSlice []string
-		for _, mac := range *val {
-			strSlice = append(strSlice, mac.String())
-		}
-		return strings.Join(strSlice, ","), err
-	case *net.IPMask:
-		val := f.AssignmentVar.(*net.IPMask)
-		return val.String(), err
-	case *[]net.IPMask:
-		val := f.AssignmentVar.(*[]net.IPMask)
-		var strSlice []string
-		for _, m := range *val {
-			strSlice = append(strSlice, m.String())
-		}
-		return strings.Join(strSlice, ","), err
-	default:
+	// every supported type, including those flaggy supports natively, has a
+	// registered converter
+	c, ok := lookupConverter(f.AssignmentVar)
+	if !ok {
 		return "", errors.New("Unknown flag assignmentVar found in flag " + f.LongName + " " + f.ShortName + ". Type not supported: " + reflect.TypeOf(f.AssignmentVar).String())
 	}
+	return c.format(f), nil
 }
@@ -1,9 +1,15 @@
 package flaggy
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -482,3 +488,595 @@ func TestInputParsing(t *testing.T) {
 		}
 	}
 }
+
+// TestByteFlagTypes tests the hex, base64 and file byte flag types
+func TestByteFlagTypes(t *testing.T) {
+	f, err := ioutil.TempFile("", "flaggy-bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("file contents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var hexFlag []byte
+	var base64Flag []byte
+	var fileFlag []byte
+	p := NewParser("testByteFlagTypes")
+	p.HexBytes(&hexFlag, "x", "hex", "hex flag")
+	p.Base64Bytes(&base64Flag, "b", "base64", "base64 flag")
+	p.BytesFromFile(&fileFlag, "f", "file", "file flag")
+	err = p.ParseArgs([]string{"-x", "0xdeadbeef", "-b", "aGVsbG8=", "-f", f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(hexFlag, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Fatal("hex flag incorrect", hexFlag)
+	}
+	if string(base64Flag) != "hello" {
+		t.Fatal("base64 flag incorrect", string(base64Flag))
+	}
+	if string(fileFlag) != "file contents" {
+		t.Fatal("file flag incorrect", string(fileFlag))
+	}
+
+	// values are formatted in their encoding for help output
+	hexValue, err := p.Flags[0].returnAssignmentVarValueAsString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexValue != "deadbeef" {
+		t.Fatal("hex flag formatted incorrectly", hexValue)
+	}
+	base64Value, err := p.Flags[1].returnAssignmentVarValueAsString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base64Value != "aGVsbG8=" {
+		t.Fatal("base64 flag formatted incorrectly", base64Value)
+	}
+}
+
+// TestByteFlagTypesInvalid tests that malformed byte flag input returns
+// errors
+func TestByteFlagTypesInvalid(t *testing.T) {
+	var b []byte
+	expectInvalidValues(t, func(p *Parser) { p.HexBytes(&b, "v", "value", "") }, "nothex")
+	expectInvalidValues(t, func(p *Parser) { p.Base64Bytes(&b, "v", "value", "") }, "not*base64")
+	expectInvalidValues(t, func(p *Parser) { p.BytesFromFile(&b, "v", "value", "") }, "/does/not/exist/x")
+}
+
+// expectInvalidValues ensures that parsing each of the values returns an
+// error for a flag named value added by addFlag
+func expectInvalidValues(t *testing.T, addFlag func(p *Parser), values ...string) {
+	t.Helper()
+	for _, value := range values {
+		p := NewParser("testInvalidValues")
+		addFlag(p)
+		err := p.ParseArgs([]string{"--value", value})
+		if err == nil {
+			t.Fatal("Expected an error for invalid input", value)
+		}
+	}
+}
+
+// TestPathFlagTypes tests the file and directory flag types
+func TestPathFlagTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flaggy-paths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	existingFile := filepath.Join(dir, "existing")
+	err = ioutil.WriteFile(existingFile, []byte("test"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FLAGGY_TEST_DIR", dir)
+
+	var fileFlag string
+	var existingFileFlag string
+	var existingDirFlag string
+	var outputFileFlag string
+	var homeFlag string
+	p := NewParser("testPathFlagTypes")
+	p.File(&fileFlag, "f", "file", "file flag")
+	p.ExistingFile(&existingFileFlag, "e", "existingFile", "existing file flag")
+	p.ExistingDir(&existingDirFlag, "d", "existingDir", "existing dir flag")
+	p.OutputFile(&outputFileFlag, "o", "outputFile", "output file flag")
+	p.File(&homeFlag, "", "home", "home flag")
+	err = p.ParseArgs([]string{
+		"-f", "$FLAGGY_TEST_DIR/missing",
+		"-e", existingFile,
+		"-d", "${FLAGGY_TEST_DIR}",
+		"-o", filepath.Join(dir, "new"),
+		"--home", "~/test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fileFlag != filepath.Join(dir, "missing") {
+		t.Fatal("file flag incorrect", fileFlag)
+	}
+	if existingFileFlag != existingFile {
+		t.Fatal("existing file flag incorrect", existingFileFlag)
+	}
+	if existingDirFlag != dir {
+		t.Fatal("existing dir flag incorrect", existingDirFlag)
+	}
+	if outputFileFlag != filepath.Join(dir, "new") {
+		t.Fatal("output file flag incorrect", outputFileFlag)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if homeFlag != filepath.Join(home, "test") {
+		t.Fatal("home flag incorrect", homeFlag)
+	}
+
+	// invalid paths return errors
+	var invalid string
+	expectInvalidValues(t, func(p *Parser) { p.ExistingFile(&invalid, "v", "value", "") }, dir, filepath.Join(dir, "missing"))
+	expectInvalidValues(t, func(p *Parser) { p.ExistingDir(&invalid, "v", "value", "") }, existingFile)
+	expectInvalidValues(t, func(p *Parser) { p.OutputFile(&invalid, "v", "value", "") }, filepath.Join(dir, "nope/new"))
+}
+
+// TestPathFlagAbsolutePath tests that path flags can be resolved to absolute
+// paths
+func TestPathFlagAbsolutePath(t *testing.T) {
+	var fileFlag string
+	p := NewParser("testPathFlagAbsolutePath")
+	p.File(&fileFlag, "f", "file", "file flag")
+	p.Flags[0].AbsolutePath = true
+	err := p.ParseArgs([]string{"-f", "relative/path"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileFlag != filepath.Join(wd, "relative/path") {
+		t.Fatal("file flag was not resolved to an absolute path", fileFlag)
+	}
+}
+
+// TestMapFlagTypes tests the map flag types
+func TestMapFlagTypes(t *testing.T) {
+	labels := map[string]string{"env": "dev", "owner": "ops"}
+	var counts map[string]int
+	var timeouts map[string]time.Duration
+	p := NewParser("testMapFlagTypes")
+	p.StringMap(&labels, "l", "label", "label flag")
+	p.IntMap(&counts, "c", "count", "count flag")
+	p.DurationMap(&timeouts, "t", "timeout", "timeout flag")
+
+	// defaults are displayed sorted by key
+	defaultValue, err := p.Flags[0].returnAssignmentVarValueAsString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaultValue != "env=dev,owner=ops" {
+		t.Fatal("map default formatted incorrectly", defaultValue)
+	}
+
+	err = p.ParseArgs([]string{"-l", "env=prod", "--label=team=infra,tier=web", "-c", "a=1,b=2", "-t", "read=5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedLabels := map[string]string{"env": "prod", "owner": "ops", "team": "infra", "tier": "web"}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatal("string map flag incorrect", labels)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"a": 1, "b": 2}) {
+		t.Fatal("int map flag incorrect", counts)
+	}
+	if !reflect.DeepEqual(timeouts, map[string]time.Duration{"read": 5 * time.Second}) {
+		t.Fatal("duration map flag incorrect", timeouts)
+	}
+}
+
+// TestMapFlagDuplicateKeys tests the duplicate key policies of map flags
+func TestMapFlagDuplicateKeys(t *testing.T) {
+	args := []string{"-l", "env=prod", "-l", "env=dev"}
+	expected := map[DuplicateKeyPolicy]string{
+		DuplicateKeyOverwrite: "dev",
+		DuplicateKeyKeepFirst: "prod",
+	}
+	for policy, expectedValue := range expected {
+		var labels map[string]string
+		p := NewParser("testMapFlagDuplicateKeys")
+		p.StringMap(&labels, "l", "label", "label flag")
+		p.Flags[0].DuplicateKeys = policy
+		err := p.ParseArgs(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if labels["env"] != expectedValue {
+			t.Fatal("duplicate key policy", policy, "resulted in", labels["env"], "but expected", expectedValue)
+		}
+	}
+
+	var labels map[string]string
+	p := NewParser("testMapFlagDuplicateKeys")
+	p.StringMap(&labels, "l", "label", "label flag")
+	p.Flags[0].DuplicateKeys = DuplicateKeyError
+	err := p.ParseArgs(args)
+	if err == nil {
+		t.Fatal("Expected an error for a duplicate key")
+	}
+
+	// a key repeated with the same value is still a duplicate
+	p = NewParser("testMapFlagDuplicateKeys")
+	p.StringMap(&labels, "l", "label", "label flag")
+	p.Flags[0].DuplicateKeys = DuplicateKeyError
+	err = p.ParseArgs([]string{"-l", "env=prod", "-l", "env=prod"})
+	if err == nil {
+		t.Fatal("Expected an error for a key repeated with the same value")
+	}
+
+	// keys set before parsing are not duplicates of keys supplied when parsing
+	p = NewParser("testMapFlagDuplicateKeys")
+	p.StringMap(&labels, "l", "label", "label flag")
+	p.Flags[0].DuplicateKeys = DuplicateKeyError
+	_, err = p.SetValueForKey("label", "env=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.ParseArgs([]string{"-l", "env=dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["env"] != "dev" {
+		t.Fatal("Expected the parsed key to replace the key set before parsing", labels["env"])
+	}
+
+	// malformed pairs return errors
+	p = NewParser("testMapFlagDuplicateKeys")
+	p.StringMap(&labels, "l", "label", "label flag")
+	err = p.ParseArgs([]string{"-l", "noequals"})
+	if err == nil {
+		t.Fatal("Expected an error for a pair without an equals sign")
+	}
+}
+
+func TestSplitSeparatedValue(t *testing.T) {
+	testCases := []struct {
+		value     string
+		separator string
+		quoted    bool
+		expected  []string
+	}{
+		{"a,b,c", ",", false, []string{"a", "b", "c"}},
+		{"single", ",", false, []string{"single"}},
+		{"", ",", false, []string{""}},
+		{`C:\dir,\\,\"`, ",", false, []string{`C:\dir`, `\\`, `\"`}},
+		{`\\server\share`, ",", false, []string{`\\server\share`}},
+		{`"quoted" word,"a,b"`, ",", false, []string{`"quoted" word`, `"a`, `b"`}},
+		{"a;b,c", ";", false, []string{"a", "b,c"}},
+		{"a::b", "::", false, []string{"a", "b"}},
+		{"a,b,c", ",", true, []string{"a", "b", "c"}},
+		{`a\,b,c`, ",", true, []string{"a,b", "c"}},
+		{`"a,b",c`, ",", true, []string{"a,b", "c"}},
+		{`"say ""hi""",x`, ",", true, []string{`say "hi"`, "x"}},
+		{`C:\dir,\\,\"`, ",", true, []string{`C:\dir`, `\`, `"`}},
+		{"a::b", "::", true, []string{"a", "b"}},
+	}
+	for _, tc := range testCases {
+		f := Flag{Separator: tc.separator, QuotedSplit: tc.quoted}
+		values, err := f.splitValue(tc.value)
+		if err != nil {
+			t.Fatal("error splitting", tc.value, err)
+		}
+		if !reflect.DeepEqual(values, tc.expected) {
+			t.Fatalf("split %q on %q as %q but expected %q", tc.value, tc.separator, values, tc.expected)
+		}
+	}
+
+	for _, value := range []string{`"unterminated`, `"quoted"trailing`} {
+		f := Flag{QuotedSplit: true}
+		_, err := f.splitValue(value)
+		if err == nil {
+			t.Fatal("expected an error splitting", value)
+		}
+	}
+}
+
+// TestSliceFlagSplitting tests that all slice types split their values and
+// that splitting is configurable per flag
+func TestSliceFlagSplitting(t *testing.T) {
+	var ints []int
+	var durations []time.Duration
+	var queries []string
+	var paths []string
+	p := NewParser("testSliceFlagSplitting")
+	p.IntSlice(&ints, "i", "int", "int slice flag")
+	p.DurationSlice(&durations, "d", "duration", "duration slice flag")
+	p.StringSlice(&queries, "q", "query", "query slice flag")
+	p.StringSlice(&paths, "p", "path", "path slice flag")
+	p.Flags[2].DisableSplit = true
+	p.Flags[3].Separator = ":"
+	err := p.ParseArgs([]string{"-i", "1,2", "-i", "3", "-d", "1s,1m", "-q", "a,b", "-p", "/bin:/usr/bin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ints, []int{1, 2, 3}) {
+		t.Fatal("int slice flag incorrect", ints)
+	}
+	if !reflect.DeepEqual(durations, []time.Duration{time.Second, time.Minute}) {
+		t.Fatal("duration slice flag incorrect", durations)
+	}
+	if !reflect.DeepEqual(queries, []string{"a,b"}) {
+		t.Fatal("unsplit string slice flag incorrect", queries)
+	}
+	if !reflect.DeepEqual(paths, []string{"/bin", "/usr/bin"}) {
+		t.Fatal("string slice flag with custom separator incorrect", paths)
+	}
+}
+
+// TestSliceDefaultPolicies tests that slice flags can append to or replace
+// their defaults
+func TestSliceDefaultPolicies(t *testing.T) {
+	items := []string{"a"}
+	ports := []int{80}
+	tags := []string{"default"}
+	p := NewParser("testSliceDefaultPolicies")
+	p.SliceDefaults = SliceDefaultReplace
+	p.StringSlice(&items, "i", "item", "item flag")
+	p.IntSlice(&ports, "p", "port", "port flag")
+	p.StringSlice(&tags, "t", "tag", "tag flag")
+	p.Flags[2].SliceDefaults = SliceDefaultAppend
+	err := p.ParseArgs([]string{"-i", "b", "-i", "c", "-p", "443", "-t", "extra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []string{"b", "c"}) {
+		t.Fatal("item slice did not replace its default", items)
+	}
+	if !reflect.DeepEqual(ports, []int{443}) {
+		t.Fatal("port slice did not replace its default", ports)
+	}
+	if !reflect.DeepEqual(tags, []string{"default", "extra"}) {
+		t.Fatal("tag slice did not append to its default", tags)
+	}
+
+	// help still displays the original defaults
+	help := Help{}
+	help.ExtractValues(p, "")
+	for _, f := range help.Flags {
+		if f.LongName == "item" && f.DefaultValue != "a" {
+			t.Fatal("item default displayed incorrectly in help", f.DefaultValue)
+		}
+		if f.LongName == "tag" && f.DefaultValue != "default" {
+			t.Fatal("tag default displayed incorrectly in help", f.DefaultValue)
+		}
+	}
+}
+
+// TestTimeSizeAndPercentageFlagTypes tests the time, byte size and percentage
+// flag types
+func TestTimeSizeAndPercentageFlagTypes(t *testing.T) {
+	var start time.Time
+	var day time.Time
+	var since time.Time
+	var times []time.Time
+	var size uint64
+	var sizes []uint64
+	var ratio float64
+	var ratios []float64
+	p := NewParser("testTimeSizeAndPercentageFlagTypes")
+	p.Time(&start, "", "s", "start", "start flag")
+	p.Time(&day, "2006-01-02", "d", "day", "day flag")
+	p.Time(&since, "", "", "since", "since flag")
+	p.TimeSlice(&times, "", "t", "times", "times flag")
+	p.ByteSize(&size, "b", "size", "size flag")
+	p.ByteSizeSlice(&sizes, "bs", "sizes", "sizes flag")
+	p.Percentage(&ratio, "r", "ratio", "ratio flag")
+	p.PercentageSlice(&ratios, "rs", "ratios", "ratios flag")
+	before := time.Now()
+	err := p.ParseArgs([]string{
+		"-s", "2021-07-08T10:00:00Z",
+		"-d", "2021-07-08",
+		"--since", "-2h",
+		"-t", "2021-07-08T10:00:00Z,2021-07-09T10:00:00Z",
+		"-b", "10MiB",
+		"-bs", "1.5GB", "-bs", "512",
+		"-r", "50%",
+		"-rs", "12.5,100%",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !start.Equal(time.Date(2021, 7, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Fatal("start flag incorrect", start)
+	}
+	if !day.Equal(time.Date(2021, 7, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("day flag incorrect", day)
+	}
+	if since.Before(before.Add(-2*time.Hour)) || since.After(time.Now().Add(-2*time.Hour)) {
+		t.Fatal("relative since flag incorrect", since)
+	}
+	if len(times) != 2 || times[1].Day() != 9 {
+		t.Fatal("times flag incorrect", times)
+	}
+	if size != 10*1024*1024 {
+		t.Fatal("size flag incorrect", size)
+	}
+	if !reflect.DeepEqual(sizes, []uint64{1500000000, 512}) {
+		t.Fatal("sizes flag incorrect", sizes)
+	}
+	if ratio != 0.5 {
+		t.Fatal("ratio flag incorrect", ratio)
+	}
+	if !reflect.DeepEqual(ratios, []float64{0.125, 1}) {
+		t.Fatal("ratios flag incorrect", ratios)
+	}
+
+	// values are displayed with their units
+	expectedStrings := map[int]string{
+		1: "2021-07-08",
+		4: "10MiB",
+		5: "1.5GB,512B",
+		6: "50%",
+	}
+	for i, expected := range expectedStrings {
+		s, err := p.Flags[i].returnAssignmentVarValueAsString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != expected {
+			t.Fatal("flag", p.Flags[i].LongName, "displayed as", s, "but expected", expected)
+		}
+	}
+
+	// invalid values return errors
+	invalidInputs := []string{"10XB", "1e30EiB", "lots"}
+	for _, input := range invalidInputs {
+		var size uint64
+		p := NewParser("testTimeSizeAndPercentageFlagTypesInvalid")
+		p.ByteSize(&size, "b", "size", "size flag")
+		err := p.ParseArgs([]string{"-b", input})
+		if err == nil {
+			t.Fatal("Expected an error for invalid byte size", input)
+		}
+	}
+}
+
+func TestNetworkFlagTypes(t *testing.T) {
+	var ip net.IP
+	var mask net.IPMask
+	var mask6 net.IPMask
+	var network net.IPNet
+	var networks []net.IPNet
+	var addr net.TCPAddr
+	var addrs []net.TCPAddr
+	var nip netip.Addr
+	var nips []netip.Addr
+	var prefix netip.Prefix
+	var prefixes []netip.Prefix
+	var u url.URL
+	var urls []url.URL
+	p := NewParser("testNetworkFlagTypes")
+	p.IP(&ip, "i", "ip", "ip flag")
+	p.IPMask(&mask, "m", "mask", "mask flag")
+	p.IPMask(&mask6, "m6", "mask6", "mask6 flag")
+	p.IPNet(&network, "n", "net", "net flag")
+	p.IPNetSlice(&networks, "ns", "nets", "nets flag")
+	p.TCPAddr(&addr, "a", "addr", "addr flag")
+	p.TCPAddrSlice(&addrs, "as", "addrs", "addrs flag")
+	p.Addr(&nip, "na", "netip", "netip flag")
+	p.AddrSlice(&nips, "nas", "netips", "netips flag")
+	p.Prefix(&prefix, "p", "prefix", "prefix flag")
+	p.PrefixSlice(&prefixes, "ps", "prefixes", "prefixes flag")
+	p.URL(&u, "u", "url", "url flag")
+	p.URLSlice(&urls, "us", "urls", "urls flag")
+	err := p.ParseArgs([]string{
+		"-i", "::1",
+		"-m", "255.255.255.0",
+		"-m6", "ffff:ffff:ffff:ffff::",
+		"-n", "10.1.2.3/8",
+		"-ns", "10.0.0.0/8,fd00::/8",
+		"-a", "127.0.0.1:8080",
+		"-as", "[::1]:443",
+		"-na", "fe80::1",
+		"-nas", "10.0.0.1,::1",
+		"-p", "192.168.0.0/16",
+		"-ps", "fd00::/8",
+		"-u", "https://example.com/path?q=1",
+		"-us", "http://a.example", "-us", "http://b.example",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ip.Equal(net.ParseIP("::1")) {
+		t.Fatal("ip flag incorrect", ip)
+	}
+	if ones, bits := mask.Size(); ones != 24 || bits != 32 {
+		t.Fatal("mask flag incorrect", mask)
+	}
+	if ones, bits := mask6.Size(); ones != 64 || bits != 128 {
+		t.Fatal("mask6 flag incorrect", mask6)
+	}
+	if network.String() != "10.0.0.0/8" {
+		t.Fatal("net flag incorrect", network.String())
+	}
+	if len(networks) != 2 || networks[1].String() != "fd00::/8" {
+		t.Fatal("nets flag incorrect", networks)
+	}
+	if addr.Port != 8080 || !addr.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Fatal("addr flag incorrect", addr.String())
+	}
+	if len(addrs) != 1 || addrs[0].String() != "[::1]:443" {
+		t.Fatal("addrs flag incorrect", addrs)
+	}
+
+	// addresses are displayed as they were supplied
+	f := Flag{AssignmentVar: &addr}
+	if value, _ := f.returnAssignmentVarValueAsString(); value != "127.0.0.1:8080" {
+		t.Fatal("addr flag displayed incorrectly", value)
+	}
+	if nip != netip.MustParseAddr("fe80::1") {
+		t.Fatal("netip flag incorrect", nip)
+	}
+	if len(nips) != 2 || nips[1] != netip.MustParseAddr("::1") {
+		t.Fatal("netips flag incorrect", nips)
+	}
+	if prefix != netip.MustParsePrefix("192.168.0.0/16") {
+		t.Fatal("prefix flag incorrect", prefix)
+	}
+	if len(prefixes) != 1 || prefixes[0].String() != "fd00::/8" {
+		t.Fatal("prefixes flag incorrect", prefixes)
+	}
+	if u.Host != "example.com" || u.Path != "/path" {
+		t.Fatal("url flag incorrect", u.String())
+	}
+	if len(urls) != 2 || urls[1].Host != "b.example" {
+		t.Fatal("urls flag incorrect", urls)
+	}
+}
+
+func TestNetworkFlagTypesInvalid(t *testing.T) {
+	var ip net.IP
+	expectInvalidValues(t, func(p *Parser) { p.IP(&ip, "v", "value", "") }, "not-valid")
+	var mask net.IPMask
+	expectInvalidValues(t, func(p *Parser) { p.IPMask(&mask, "v", "value", "") }, "not-valid", "255.0.255.0", "ffff::ffff")
+	var ipNet net.IPNet
+	expectInvalidValues(t, func(p *Parser) { p.IPNet(&ipNet, "v", "value", "") }, "not-valid")
+	var tcpAddr net.TCPAddr
+	expectInvalidValues(t, func(p *Parser) { p.TCPAddr(&tcpAddr, "v", "value", "") }, "not-valid", "localhost:80", "127.0.0.1:http", "127.0.0.1:70000")
+	var addr netip.Addr
+	expectInvalidValues(t, func(p *Parser) { p.Addr(&addr, "v", "value", "") }, "not-valid")
+	var prefix netip.Prefix
+	expectInvalidValues(t, func(p *Parser) { p.Prefix(&prefix, "v", "value", "") }, "not-valid")
+	var u url.URL
+	expectInvalidValues(t, func(p *Parser) { p.URL(&u, "v", "value", "") }, "not-valid")
+}
+
+// TestQuotedSplitIsOptIn tests that backslashes and quotes are only special
+// in slice values of flags that set QuotedSplit
+func TestQuotedSplitIsOptIn(t *testing.T) {
+	var shares, queries, quoted []string
+	p := NewParser("testQuotedSplitIsOptIn")
+	p.StringSlice(&shares, "", "share", "share slice flag")
+	p.StringSlice(&queries, "", "q", "query slice flag")
+	p.StringSlice(&quoted, "", "quoted", "quoted slice flag")
+	p.Flags[2].QuotedSplit = true
+	err := p.ParseArgs([]string{"--share", `\\server\share`, "--q", `"quoted" word`, "--quoted", `"a,b",c\,d`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shares, []string{`\\server\share`}) || !reflect.DeepEqual(queries, []string{`"quoted" word`}) {
+		t.Fatal("values without QuotedSplit changed:", shares, queries)
+	}
+	if !reflect.DeepEqual(quoted, []string{"a,b", "c,d"}) {
+		t.Fatal("values with QuotedSplit incorrect:", quoted)
+	}
+}
@@ -3,21 +3,25 @@ package flaggy
 // defaultHelpTemplate is the help template used by default
 // {{if (or (or (gt (len .StringFlags) 0) (gt (len .IntFlags) 0)) (gt (len .BoolFlags) 0))}}
 // {{if (or (gt (len .StringFlags) 0) (gt (len .BoolFlags) 0))}}
-const defaultHelpTemplate = `{{.CommandName}}{{if .Description}} - {{.Description}}{{end}}{{if .PrependMessage}}
+const defaultHelpTemplate = `{{command .CommandName}}{{if .Description}} - {{.Description}}{{end}}{{if .PrependMessage}}
 {{.PrependMessage}}{{end}}
 {{if .UsageString}}
-  Usage:
+  {{heading "Usage:"}}
     {{.UsageString}}{{end}}{{if .Positionals}}
 
-  Positional Variables: {{range .Positionals}}
-    {{.Name}}  {{.Spacer}}{{if .Description}} {{.Description}}{{end}}{{if .DefaultValue}} (default: {{.DefaultValue}}){{else}}{{if .Required}} (Required){{end}}{{end}}{{end}}{{end}}{{if .Subcommands}}
-
-  Subcommands: {{range .Subcommands}}
-    {{.LongName}}{{if .ShortName}} ({{.ShortName}}){{end}}{{if .Position}}{{if gt .Position 1}}  (position {{.Position}}){{end}}{{end}}{{if .Description}}   {{.Spacer}}{{.Description}}{{end}}{{end}}
-{{end}}{{if (gt (len .Flags) 0)}}
-  Flags: {{if .Flags}}{{range .Flags}}
-    {{if .ShortName}}-{{.ShortName}} {{else}}   {{end}}{{if .LongName}}--{{.LongName}}{{end}}{{if .Description}}   {{.Spacer}}{{.Description}}{{if .DefaultValue}} (default: {{.DefaultValue}}){{end}}{{end}}{{end}}{{end}}
+  {{heading "Positional Variables:"}} {{range .Positionals}}
+    {{positional .Name}}  {{.Spacer}}{{if .Description}} {{.Description}}{{end}}{{if .DefaultValue}} (default: {{.DefaultValue}}){{else}}{{if .Required}} (Required){{end}}{{end}}{{end}}{{end}}{{range $index, $group := .SubcommandGroups}}{{if eq $index 0}}
+{{end}}
+  {{if .Name}}{{heading (print .Name ":")}}{{else}}{{heading "Subcommands:"}}{{end}} {{range .Subcommands}}
+    {{command .LongName}}{{if .ShortName}} ({{command .ShortName}}){{end}}{{if .Position}}{{if gt .Position 1}}  (position {{.Position}}){{end}}{{end}}{{if .Description}}   {{.Spacer}}{{.Description}}{{end}}{{end}}
+{{end}}{{range .FlagGroups}}
+  {{if .Name}}{{heading (print .Name ":")}}{{else}}{{heading "Flags:"}}{{end}} {{range .Flags}}
+    {{if .ShortName}}{{flagName (print "-" .ShortName)}} {{else}}   {{end}}{{if .LongName}}{{flagName (print "--" .LongName)}}{{end}}{{if .ValueName}} {{.ValueName}}{{end}}{{if .Description}}   {{.Spacer}}{{.Description}}{{if .DefaultValue}} (default: {{.DefaultValue}}){{end}}{{end}}{{end}}
+{{end}}{{if .Examples}}
+  {{heading "Examples:"}} {{range .Examples}}{{if .Description}}
+    # {{.Description}}{{end}}
+    {{.Command}}{{end}}
 {{end}}{{if .AppendMessage}}{{.AppendMessage}}
 {{end}}{{if .Message}}
-{{.Message}}{{end}}
+{{errorText .Message}}{{end}}
 `
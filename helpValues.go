@@ -9,15 +9,18 @@ import (
 
 // Help represents the values needed to render a Help page
 type Help struct {
-	Subcommands    []HelpSubcommand
-	Positionals    []HelpPositional
-	Flags          []HelpFlag
-	UsageString    string
-	CommandName    string
-	PrependMessage string
-	AppendMessage  string
-	Message        string
-	Description    string
+	Subcommands      []HelpSubcommand
+	SubcommandGroups []HelpSubcommandGroup
+	Positionals      []HelpPositional
+	Flags            []HelpFlag
+	FlagGroups       []HelpFlagGroup
+	Examples         []Example
+	UsageString      string
+	CommandName      string
+	PrependMessage   string
+	AppendMessage    string
+	Message          string
+	Description      string
 }
 
 // HelpSubcommand is used to template subcommand Help output
@@ -26,9 +29,18 @@ type HelpSubcommand struct {
 	LongName    string
 	Description string
 	Position    int
+	Group       string
 	Spacer      string
 }
 
+// HelpSubcommandGroup is used to template a group of subcommands listed
+// together under one heading.  The group with a blank name holds subcommands
+// without a group.
+type HelpSubcommandGroup struct {
+	Name        string
+	Subcommands []HelpSubcommand
+}
+
 // HelpPositional is used to template positional Help output
 type HelpPositional struct {
 	Name         string
@@ -43,11 +55,21 @@ type HelpPositional struct {
 type HelpFlag struct {
 	ShortName    string
 	LongName     string
+	ValueName    string // the placeholder for the flag's value.  Blank for bools
+	Type         string // a short description of the flag's value type
 	Description  string
 	DefaultValue string
+	Group        string
 	Spacer       string
 }
 
+// HelpFlagGroup is used to template a group of flags listed together under
+// one heading.  The group with a blank name holds flags without a group.
+type HelpFlagGroup struct {
+	Name  string
+	Flags []HelpFlag
+}
+
 // ExtractValues extracts Help template values from a subcommand and its parent
 // parser. The parser is required in order to detect default flag settings
 // for help and version output.
@@ -65,6 +87,8 @@ func (h *Help) ExtractValues(p *Parser, message string) {
 	h.CommandName = p.subcommandContext.Name
 	// description
 	h.Description = p.subcommandContext.Description
+	// examples
+	h.Examples = p.subcommandContext.Examples
 
 	maxLength := getLongestNameLength(p.subcommandContext.Subcommands, 0)
 
@@ -78,10 +102,12 @@ func (h *Help) ExtractValues(p *Parser, message string) {
 			LongName:    cmd.Name,
 			Description: cmd.Description,
 			Position:    cmd.Position,
+			Group:       cmd.Group,
 			Spacer:      makeSpacer(cmd.Name, maxLength),
 		}
 		h.Subcommands = append(h.Subcommands, newHelpSubcommand)
 	}
+	h.SubcommandGroups = groupHelpSubcommands(h.Subcommands, p.subcommandContext.subcommandGroupOrder)
 
 	maxLength = getLongestNameLength(p.subcommandContext.PositionalFlags, 0)
 
@@ -106,7 +132,8 @@ func (h *Help) ExtractValues(p *Parser, message string) {
 		maxLength = len(helpFlagLongName)
 	}
 	maxLength = getLongestNameLength(p.subcommandContext.Flags, maxLength)
-	maxLength = getLongestNameLength(p.Flags, maxLength)
+	inheritedFlags := p.inheritedFlags()
+	maxLength = getLongestNameLength(inheritedFlags, maxLength)
 
 	// if the built-in version flag is enabled, then add it as a help flag
 	if p.ShowVersionWithVersionFlag {
@@ -135,8 +162,15 @@ func (h *Help) ExtractValues(p *Parser, message string) {
 	// go through every flag in the subcommand and add it to help output
 	h.parseFlagsToHelpFlags(p.subcommandContext.Flags, maxLength)
 
-	// go through every flag in the parent parser and add it to help output
-	h.parseFlagsToHelpFlags(p.Flags, maxLength)
+	// go through every flag of the parent subcommands that is not local and
+	// add it to help output
+	h.parseFlagsToHelpFlags(inheritedFlags, maxLength)
+
+	// sort the flags into their groups, using the group order of the current
+	// subcommand followed by the group order of the parser
+	groupOrder := append([]string{}, p.subcommandContext.flagGroupOrder...)
+	groupOrder = append(groupOrder, p.flagGroupOrder...)
+	h.FlagGroups = groupHelpFlags(h.Flags, groupOrder)
 
 	// formulate the usage string
 	// first, we capture all the command and positional names by position
@@ -226,9 +260,12 @@ func (h *Help) parseFlagsToHelpFlags(flags []*Flag, maxLength int) {
 		newHelpFlag := HelpFlag{
 			ShortName:    f.ShortName,
 			LongName:     f.LongName,
+			ValueName:    f.valuePlaceholder(),
+			Type:         f.typeHint(),
 			Description:  f.Description,
 			DefaultValue: defaultValue,
-			Spacer:       makeSpacer(f.LongName, maxLength),
+			Group:        f.Group,
+			Spacer:       makeSpacer(f.helpName(), maxLength),
 		}
 		h.AddFlagToHelp(newHelpFlag)
 	}
@@ -245,6 +282,85 @@ func (h *Help) AddFlagToHelp(f HelpFlag) {
 		}
 	}
 	h.Flags = append(h.Flags, f)
+
+	// keep the flag groups in sync once they have been built
+	if len(h.FlagGroups) == 0 {
+		return
+	}
+	for i := range h.FlagGroups {
+		if h.FlagGroups[i].Name == f.Group {
+			h.FlagGroups[i].Flags = append(h.FlagGroups[i].Flags, f)
+			return
+		}
+	}
+	h.FlagGroups = append(h.FlagGroups, HelpFlagGroup{Name: f.Group, Flags: []HelpFlag{f}})
+}
+
+// groupHelpFlags sorts help flags into groups.  See groupByName.
+func groupHelpFlags(flags []HelpFlag, groupOrder []string) []HelpFlagGroup {
+	names, grouped := groupByName(flags, groupOrder, func(f HelpFlag) string {
+		return f.Group
+	})
+	var groups []HelpFlagGroup
+	for i, name := range names {
+		groups = append(groups, HelpFlagGroup{Name: name, Flags: grouped[i]})
+	}
+	return groups
+}
+
+// groupHelpSubcommands sorts help subcommands into groups.  See groupByName.
+func groupHelpSubcommands(subcommands []HelpSubcommand, groupOrder []string) []HelpSubcommandGroup {
+	names, grouped := groupByName(subcommands, groupOrder, func(cmd HelpSubcommand) string {
+		return cmd.Group
+	})
+	var groups []HelpSubcommandGroup
+	for i, name := range names {
+		groups = append(groups, HelpSubcommandGroup{Name: name, Subcommands: grouped[i]})
+	}
+	return groups
+}
+
+// groupByName sorts items into groups by the name returned by group.  Items
+// without a group come first, followed by the groups named in groupOrder,
+// followed by any remaining groups in the order their first item appears.
+// Groups without items are dropped.  The name of each group is returned
+// along with its items.
+func groupByName[T any](items []T, groupOrder []string, group func(T) string) ([]string, [][]T) {
+	var names []string
+	var grouped [][]T
+	groupIndex := make(map[string]int)
+
+	// addGroup adds an empty group if it does not already exist
+	addGroup := func(name string) {
+		if _, exists := groupIndex[name]; exists {
+			return
+		}
+		groupIndex[name] = len(names)
+		names = append(names, name)
+		grouped = append(grouped, nil)
+	}
+
+	addGroup("")
+	for _, name := range groupOrder {
+		addGroup(name)
+	}
+	for _, item := range items {
+		name := group(item)
+		addGroup(name)
+		i := groupIndex[name]
+		grouped[i] = append(grouped[i], item)
+	}
+
+	// drop any groups that did not end up with items
+	var populatedNames []string
+	var populatedGroups [][]T
+	for i, name := range names {
+		if len(grouped[i]) > 0 {
+			populatedNames = append(populatedNames, name)
+			populatedGroups = append(populatedGroups, grouped[i])
+		}
+	}
+	return populatedNames, populatedGroups
 }
 
 // getLongestNameLength takes a slice of any supported flag and returns the length of the longest of their names
@@ -263,7 +379,7 @@ func getLongestNameLength(slice interface{}, min int) int {
 		case *Subcommand:
 			name = t.Name
 		case *Flag:
-			name = t.LongName
+			name = t.helpName()
 		case *PositionalValue:
 			name = t.Name
 		default:
@@ -1,6 +1,8 @@
 package flaggy_test
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,3 +66,133 @@ func TestHelpOutput(t *testing.T) {
 	p.ParseArgs([]string{"subcommandA", "subcommandB", "hiddenPositional1"})
 	p.ShowHelpWithMessage("This is a help message on exit")
 }
+
+// TestHelpFlagGroups tests that grouped flags are displayed under their own
+// headings in the configured order
+func TestHelpFlagGroups(t *testing.T) {
+	p := flaggy.NewParser("testHelpFlagGroups")
+	var port int
+	var host string
+	var verbose bool
+	var logFile string
+	p.Int(&port, "p", "port", "Port to listen on")
+	p.String(&host, "", "host", "Host to listen on")
+	p.Bool(&verbose, "v", "verbose", "Verbose output")
+	p.String(&logFile, "", "logFile", "File to log to")
+	p.SetFlagGroup("Networking", "port", "host")
+	p.SetFlagGroup("Logging", "v", "logFile")
+	p.SetFlagGroupOrder("Logging", "Networking")
+
+	var buf bytes.Buffer
+	p.SetErrOutput(&buf)
+	p.ShowHelp()
+	out := buf.String()
+
+	flagsIndex := strings.Index(out, "Flags:")
+	loggingIndex := strings.Index(out, "Logging:")
+	networkingIndex := strings.Index(out, "Networking:")
+	if flagsIndex < 0 || loggingIndex < 0 || networkingIndex < 0 {
+		t.Fatal("help output is missing flag group headings:", out)
+	}
+	if !(flagsIndex < loggingIndex && loggingIndex < networkingIndex) {
+		t.Fatal("flag groups were not displayed in order:", out)
+	}
+	if strings.Index(out, "--verbose") < loggingIndex || strings.Index(out, "--verbose") > networkingIndex {
+		t.Fatal("verbose flag was not displayed in the Logging group:", out)
+	}
+	if strings.Index(out, "--port") < networkingIndex {
+		t.Fatal("port flag was not displayed in the Networking group:", out)
+	}
+}
+
+// TestHelpSubcommandGroups tests that grouped subcommands are displayed under
+// their own headings in the configured order
+func TestHelpSubcommandGroups(t *testing.T) {
+	p := flaggy.NewParser("testHelpSubcommandGroups")
+	scA := flaggy.NewSubcommand("serve")
+	scB := flaggy.NewSubcommand("trace")
+	scB.Group = "Debugging"
+	scC := flaggy.NewSubcommand("remove")
+	scC.Group = "Management Commands"
+	p.AttachSubcommand(scA, 1)
+	p.AttachSubcommand(scB, 1)
+	p.AttachSubcommand(scC, 2)
+	p.SetSubcommandGroupOrder("Management Commands", "Debugging")
+
+	var buf bytes.Buffer
+	p.SetErrOutput(&buf)
+	p.ShowHelpOnUnexpected = false
+	err := p.ParseArgs([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ShowHelp()
+	out := buf.String()
+
+	subcommandsIndex := strings.Index(out, "Subcommands:")
+	managementIndex := strings.Index(out, "Management Commands:")
+	debuggingIndex := strings.Index(out, "Debugging:")
+	if subcommandsIndex < 0 || managementIndex < 0 || debuggingIndex < 0 {
+		t.Fatal("help output is missing subcommand group headings:", out)
+	}
+	if !(subcommandsIndex < managementIndex && managementIndex < debuggingIndex) {
+		t.Fatal("subcommand groups were not displayed in order:", out)
+	}
+	if !strings.Contains(out[managementIndex:debuggingIndex], "remove  (position 2)") {
+		t.Fatal("remove subcommand was not displayed with its position in the Management Commands group:", out)
+	}
+}
+
+// TestHelpValuePlaceholders tests that flags show a type hint or their
+// ValueName after their name in help output
+func TestHelpValuePlaceholders(t *testing.T) {
+	p := flaggy.NewParser("testHelpValuePlaceholders")
+	var port int
+	var timeout time.Duration
+	var names []string
+	var verbose bool
+	p.Int(&port, "p", "port", "Port to listen on")
+	p.Duration(&timeout, "t", "timeout", "Request timeout")
+	p.StringSlice(&names, "n", "name", "Names to use")
+	p.Bool(&verbose, "v", "verbose", "Verbose output")
+	p.Flags[0].ValueName = "PORT"
+
+	var buf bytes.Buffer
+	p.SetErrOutput(&buf)
+	p.ShowHelp()
+	out := buf.String()
+
+	for _, expected := range []string{"--port PORT ", "--timeout duration ", "--name strings "} {
+		if !strings.Contains(out, expected) {
+			t.Fatal("help output did not contain", expected, ":", out)
+		}
+	}
+	if strings.Contains(out, "--verbose bool") {
+		t.Fatal("bool flag was displayed with a placeholder:", out)
+	}
+}
+
+// TestHelpExamples tests that subcommand examples are displayed in help
+func TestHelpExamples(t *testing.T) {
+	p := flaggy.NewParser("testHelpExamples")
+	sc := flaggy.NewSubcommand("serve")
+	sc.Examples = []flaggy.Example{
+		{Command: "testHelpExamples serve --port 80", Description: "Serve on port 80"},
+		{Command: "testHelpExamples serve"},
+	}
+	p.AttachSubcommand(sc, 1)
+
+	var buf bytes.Buffer
+	p.SetErrOutput(&buf)
+	err := p.ParseArgs([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ShowHelp()
+	out := buf.String()
+
+	expected := "  Examples: \n    # Serve on port 80\n    testHelpExamples serve --port 80\n    testHelpExamples serve\n"
+	if !strings.Contains(out, expected) {
+		t.Fatal("help output did not contain the examples section:", out)
+	}
+}
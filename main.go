@@ -8,8 +8,11 @@ package flaggy // import "github.com/integrii/flaggy"
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -24,7 +27,8 @@ const helpFlagShortName = "h"
 // defaultVersion is applied to parsers when they are created
 const defaultVersion = "0.0.0"
 
-// DebugMode indicates that debug output should be enabled
+// DebugMode indicates that debug output should be enabled.  It must not be
+// changed while parsers are parsing on other goroutines.
 var DebugMode bool
 
 // DefaultHelpTemplate is the help template that will be used
@@ -76,6 +80,30 @@ func ParseArgs(args []string) {
 	}
 }
 
+// Lookup returns the flag with the specified short or long name from the
+// most specific subcommand used by the default parser, or any of its parents
+func Lookup(name string) *Flag {
+	return DefaultParser.Lookup(name)
+}
+
+// Changed determines if the flag with the specified short or long name was
+// set while parsing with the default parser
+func Changed(name string) bool {
+	return DefaultParser.Changed(name)
+}
+
+// Walk calls fn for the default parser and every subcommand beneath it.  See
+// Parser.Walk for the order subcommands are visited in.
+func Walk(fn func(path []*Subcommand) error) error {
+	return DefaultParser.Walk(fn)
+}
+
+// FindSubcommand returns the subcommand of the default parser found by
+// following the supplied names, or nil if there is none
+func FindSubcommand(path ...string) *Subcommand {
+	return DefaultParser.FindSubcommand(path...)
+}
+
 // String adds a new string flag
 func String(assignmentVar *string, shortName string, longName string, description string) {
 	DefaultParser.add(assignmentVar, shortName, longName, description)
@@ -99,11 +127,118 @@ func BoolSlice(assignmentVar *[]bool, shortName string, longName string, descrip
 }
 
 // ByteSlice adds a new slice of bytes flag
-// Specify the flag multiple times to fill the slice.  Takes hex as input.
+// Specify the flag multiple times to fill the slice.  Each value is a single
+// decimal byte.  See HexBytes and Base64Bytes for encoded input.
 func ByteSlice(assignmentVar *[]byte, shortName string, longName string, description string) {
 	DefaultParser.add(assignmentVar, shortName, longName, description)
 }
 
+// Time adds a new time.Time flag.  Values are parsed with the supplied layout,
+// which defaults to time.RFC3339 when blank.  The value "now" and values
+// relative to now such as -2h or +30m are also accepted.
+func Time(assignmentVar *time.Time, layout string, shortName string, longName string, description string) {
+	f := DefaultParser.add(assignmentVar, shortName, longName, description)
+	f.timeLayout = layout
+}
+
+// TimeSlice adds a new time.Time slice flag.  Values are parsed the same way
+// as Time flags.
+// Specify the flag multiple times to fill the slice.
+func TimeSlice(assignmentVar *[]time.Time, layout string, shortName string, longName string, description string) {
+	f := DefaultParser.add(assignmentVar, shortName, longName, description)
+	f.timeLayout = layout
+}
+
+// ByteSize adds a new byte size flag that parses sizes with units into a
+// number of bytes.  KB, MB and friends are powers of 1000 while KiB, MiB and
+// friends are powers of 1024.
+// Example values: 512, 10MiB, 1.5GB
+func ByteSize(assignmentVar *uint64, shortName string, longName string, description string) {
+	DefaultParser.add((*byteSize)(assignmentVar), shortName, longName, description)
+}
+
+// ByteSizeSlice adds a new byte size slice flag.
+// Specify the flag multiple times to fill the slice.
+func ByteSizeSlice(assignmentVar *[]uint64, shortName string, longName string, description string) {
+	DefaultParser.add((*byteSizeSlice)(assignmentVar), shortName, longName, description)
+}
+
+// Percentage adds a new percentage flag.  The value is stored as a fraction,
+// so 50% is stored as 0.5.  The % sign is optional.
+// Example values: 50%, 12.5
+func Percentage(assignmentVar *float64, shortName string, longName string, description string) {
+	DefaultParser.add((*percentage)(assignmentVar), shortName, longName, description)
+}
+
+// PercentageSlice adds a new percentage slice flag.
+// Specify the flag multiple times to fill the slice.
+func PercentageSlice(assignmentVar *[]float64, shortName string, longName string, description string) {
+	DefaultParser.add((*percentageSlice)(assignmentVar), shortName, longName, description)
+}
+
+// StringMap adds a new map of strings flag.  Values are key=value pairs,
+// either comma separated or supplied by specifying the flag multiple times.
+// Example values: env=prod, team=infra,tier=web
+func StringMap(assignmentVar *map[string]string, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// IntMap adds a new map of ints flag.  Values are key=value pairs, either
+// comma separated or supplied by specifying the flag multiple times.
+func IntMap(assignmentVar *map[string]int, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// DurationMap adds a new map of time.Duration flag.  Values are key=value
+// pairs, either comma separated or supplied by specifying the flag multiple
+// times.  Durations are parsed with time.ParseDuration().
+func DurationMap(assignmentVar *map[string]time.Duration, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// File adds a new file path flag.  Environment variables and a leading ~ are
+// expanded, but the path is not required to exist.
+func File(assignmentVar *string, shortName string, longName string, description string) {
+	DefaultParser.add((*filePath)(assignmentVar), shortName, longName, description)
+}
+
+// ExistingFile adds a new file path flag that must refer to an existing,
+// readable regular file.  Environment variables and a leading ~ are expanded.
+func ExistingFile(assignmentVar *string, shortName string, longName string, description string) {
+	DefaultParser.add((*existingFilePath)(assignmentVar), shortName, longName, description)
+}
+
+// ExistingDir adds a new directory path flag that must refer to an existing,
+// readable directory.  Environment variables and a leading ~ are expanded.
+func ExistingDir(assignmentVar *string, shortName string, longName string, description string) {
+	DefaultParser.add((*existingDirPath)(assignmentVar), shortName, longName, description)
+}
+
+// OutputFile adds a new file path flag that must refer to a writable regular
+// file, or to a file that does not exist yet within an existing directory.
+// Environment variables and a leading ~ are expanded.
+func OutputFile(assignmentVar *string, shortName string, longName string, description string) {
+	DefaultParser.add((*outputFilePath)(assignmentVar), shortName, longName, description)
+}
+
+// HexBytes adds a new bytes flag that takes hex encoded input, with or without
+// a leading 0x.  Example value: 0xdeadbeef
+func HexBytes(assignmentVar *[]byte, shortName string, longName string, description string) {
+	DefaultParser.add((*hexBytes)(assignmentVar), shortName, longName, description)
+}
+
+// Base64Bytes adds a new bytes flag that takes standard or URL safe base64
+// encoded input.
+func Base64Bytes(assignmentVar *[]byte, shortName string, longName string, description string) {
+	DefaultParser.add((*base64Bytes)(assignmentVar), shortName, longName, description)
+}
+
+// BytesFromFile adds a new bytes flag that takes a file path as input and is
+// set to the contents of that file.
+func BytesFromFile(assignmentVar *[]byte, shortName string, longName string, description string) {
+	DefaultParser.add((*fileBytes)(assignmentVar), shortName, longName, description)
+}
+
 // Duration adds a new time.Duration flag.
 // Input format is described in time.ParseDuration().
 // Example values: 1h, 1h50m, 32s
@@ -273,17 +408,77 @@ func HardwareAddrSlice(assignmentVar *[]net.HardwareAddr, shortName string, long
 	DefaultParser.add(assignmentVar, shortName, longName, description)
 }
 
-// IPMask adds a new net.IPMask flag. IPv4 Only.
+// IPMask adds a new net.IPMask flag.  Accepts IPv4 masks such as
+// 255.255.255.0 and IPv6 masks such as ffff:ffff:ffff:ffff::
 func IPMask(assignmentVar *net.IPMask, shortName string, longName string, description string) {
 	DefaultParser.add(assignmentVar, shortName, longName, description)
 }
 
-// IPMaskSlice adds a new net.HardwareAddr slice flag. IPv4 only.
+// IPMaskSlice adds a new net.IPMask slice flag.
 // Specify the flag multiple times to fill the slice.
 func IPMaskSlice(assignmentVar *[]net.IPMask, shortName string, longName string, description string) {
 	DefaultParser.add(assignmentVar, shortName, longName, description)
 }
 
+// IPNet adds a new net.IPNet flag that takes CIDR notation.
+// Example values: 10.0.0.0/8, fd00::/8
+func IPNet(assignmentVar *net.IPNet, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// IPNetSlice adds a new net.IPNet slice flag.
+// Specify the flag multiple times to fill the slice.
+func IPNetSlice(assignmentVar *[]net.IPNet, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// TCPAddr adds a new net.TCPAddr flag that takes an ip:port.  Host names are
+// not supported, so parsing never performs DNS lookups.
+// Example values: 127.0.0.1:8080, [::1]:443, :80
+func TCPAddr(assignmentVar *net.TCPAddr, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// TCPAddrSlice adds a new net.TCPAddr slice flag.
+// Specify the flag multiple times to fill the slice.
+func TCPAddrSlice(assignmentVar *[]net.TCPAddr, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// Addr adds a new netip.Addr flag.
+func Addr(assignmentVar *netip.Addr, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// AddrSlice adds a new netip.Addr slice flag.
+// Specify the flag multiple times to fill the slice.
+func AddrSlice(assignmentVar *[]netip.Addr, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// Prefix adds a new netip.Prefix flag that takes CIDR notation.
+func Prefix(assignmentVar *netip.Prefix, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// PrefixSlice adds a new netip.Prefix slice flag.
+// Specify the flag multiple times to fill the slice.
+func PrefixSlice(assignmentVar *[]netip.Prefix, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// URL adds a new url.URL flag.  The URL must be absolute.
+// Example values: https://example.com/path
+func URL(assignmentVar *url.URL, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
+// URLSlice adds a new url.URL slice flag.
+// Specify the flag multiple times to fill the slice.
+func URLSlice(assignmentVar *[]url.URL, shortName string, longName string, description string) {
+	DefaultParser.add(assignmentVar, shortName, longName, description)
+}
+
 // AttachSubcommand adds a subcommand for parsing
 func AttachSubcommand(subcommand *Subcommand, relativePosition int) {
 	DefaultParser.AttachSubcommand(subcommand, relativePosition)
@@ -309,6 +504,24 @@ func SetName(name string) {
 	DefaultParser.Name = name
 }
 
+// SetOutput sets the writer that the default package command parser writes
+// version output to
+func SetOutput(w io.Writer) {
+	DefaultParser.SetOutput(w)
+}
+
+// SetErrOutput sets the writer that the default package command parser writes
+// help and error output to
+func SetErrOutput(w io.Writer) {
+	DefaultParser.SetErrOutput(w)
+}
+
+// SetTheme sets the color theme of the default package command parser.  Pass
+// nil to disable colors.
+func SetTheme(theme *Theme) {
+	DefaultParser.Theme = theme
+}
+
 // ShowHelpAndExit shows parser help and exits with status code 2
 func ShowHelpAndExit(message string) {
 	ShowHelp(message)
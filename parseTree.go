@@ -0,0 +1,432 @@
+package flaggy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind identifies what an arg was parsed as
+type tokenKind int
+
+// The kinds of tokens produced while parsing args
+const (
+	tokenPositional    tokenKind = iota // a positional value, or an arg that matched nothing
+	tokenSubcommand                     // the name of a subcommand
+	tokenFlag                           // a flag without an attached value: -f value or -v
+	tokenFlagWithValue                  // a flag with an attached value: -f=value
+	tokenFlagValue                      // the value of the preceding flag
+	tokenHelp                           // a request for help: -h or --help
+	tokenTerminator                     // the -- that ends parsing
+	tokenTrailing                       // an arg after the terminator
+)
+
+// token is a single arg along with what it was parsed as.  The index refers
+// to the position of the arg in the args being parsed.
+type token struct {
+	kind       tokenKind
+	index      int
+	arg        string           // the arg exactly as it was supplied
+	name       string           // the flag name without dashes
+	value      string           // the value of a flag or positional value
+	sc         *Subcommand      // the subcommand that owns the flag, positional value or subcommand name
+	flag       *Flag            // the flag resolved for a flag token
+	positional *PositionalValue // the positional value assigned by a positional token
+}
+
+// tokenizer splits args into tokens one at a time.  Whether a flag consumes
+// the following arg as its value depends on the subcommands parsed so far, so
+// the parser asks for flag values explicitly with scanValue.
+type tokenizer struct {
+	args       []string
+	next       int  // the index of the next arg to scan
+	terminated bool // indicates the terminator was scanned
+}
+
+// scan returns a token for the next arg, classified only by its form.  False
+// is returned when no args remain.
+func (t *tokenizer) scan() (*token, bool) {
+	if t.next >= len(t.args) {
+		return nil, false
+	}
+	tok := &token{index: t.next, arg: t.args[t.next]}
+	t.next++
+
+	if t.terminated {
+		tok.kind = tokenTrailing
+		tok.value = tok.arg
+		return tok, true
+	}
+
+	switch determineArgType(tok.arg) {
+	case argIsFinal:
+		tok.kind = tokenTerminator
+		t.terminated = true
+	case argIsFlagWithSpace:
+		tok.kind = tokenFlag
+		tok.name = parseFlagToName(tok.arg)
+	case argIsFlagWithValue:
+		tok.kind = tokenFlagWithValue
+		tok.name, tok.value = parseArgWithValue(tok.arg)
+	default:
+		tok.kind = tokenPositional
+		tok.value = tok.arg
+	}
+	return tok, true
+}
+
+// scanValue returns the next arg as a flag value, regardless of its form.
+// False is returned when no args remain.
+func (t *tokenizer) scanValue() (*token, bool) {
+	if t.next >= len(t.args) {
+		return nil, false
+	}
+	tok := &token{kind: tokenFlagValue, index: t.next, arg: t.args[t.next], value: t.args[t.next]}
+	t.next++
+	return tok, true
+}
+
+// parseTree is the result of parsing args in a single pass.  It holds a token
+// for every arg, in order, along with the chain of subcommands used.
+type parseTree struct {
+	tokens           []*token
+	chain            []*Subcommand     // the parser and each subcommand used, from least to most specific
+	chainIndexes     []int             // the index of the arg that selected each subcommand in the chain.  -1 for the parser.
+	helpRequested    bool              // indicates -h or --help was supplied
+	versionRequested bool              // indicates --version was supplied.  Parsing stops at the version flag.
+	fileValues       map[string]string // file values already loaded while applying the tree, by reference
+}
+
+// deepest returns the most specific subcommand used
+func (tree *parseTree) deepest() *Subcommand {
+	return tree.chain[len(tree.chain)-1]
+}
+
+// enteredAt returns the index of the arg that selected the specified
+// subcommand of the chain
+func (tree *parseTree) enteredAt(sc *Subcommand) int {
+	for i, chained := range tree.chain {
+		if chained == sc {
+			return tree.chainIndexes[i]
+		}
+	}
+	return -1
+}
+
+// parseError describes args that could not be parsed into a parse tree.
+// ParseArgs displays it along with help and exits, while ParseArgsInto returns
+// it.
+type parseError struct {
+	message  string
+	sc       *Subcommand // the most specific subcommand found before the error
+	position int         // the relative position of an unexpected positional arg
+	suggest  bool        // indicates subcommands exist at the position and should be suggested
+}
+
+func (e *parseError) Error() string {
+	return e.message
+}
+
+// buildParseTree tokenizes and parses the args in a single pass.  Positional
+// args select subcommands and positional values relative to the most specific
+// subcommand found before them.  Flags are resolved once the full chain of
+// subcommands is known, by resolveParseTree.  Positional args that match
+// nothing are returned as a *parseError when rejectUnexpected is set, and
+// are otherwise left for findUnknownArgs to report.  Building the tree does
+// not change the parser or its subcommands.
+func (p *Parser) buildParseTree(args []string, rejectUnexpected bool) (*parseTree, error) {
+	tree := &parseTree{
+		chain:        []*Subcommand{&p.Subcommand},
+		chainIndexes: []int{-1},
+	}
+	err := p.conflictWithBuiltins(&p.Subcommand)
+	if err != nil {
+		return tree, err
+	}
+
+	// the position of the next positional arg, relative to the most specific
+	// subcommand found so far
+	var position int
+
+	t := &tokenizer{args: args}
+	for {
+		tok, ok := t.scan()
+		if !ok {
+			break
+		}
+		tree.tokens = append(tree.tokens, tok)
+		debugPrint("parsing arg:", tok.arg)
+
+		if tok.kind == tokenTerminator || tok.kind == tokenTrailing {
+			continue
+		}
+
+		// display the version or request help when the built-in flags are
+		// passed, with or without dashes
+		name := parseFlagToName(tok.arg)
+		if p.ShowVersionWithVersionFlag && name == versionFlagLongName {
+			tree.versionRequested = true
+			return tree, nil
+		}
+		if p.ShowHelpWithHFlag && (name == helpFlagShortName || name == helpFlagLongName) {
+			tok.kind = tokenHelp
+			tree.helpRequested = true
+			continue
+		}
+
+		// flags without a name, such as --= or -=x, are left unresolved
+		// rather than matching flags that have no short or long name
+		if (tok.kind == tokenFlag || tok.kind == tokenFlagWithValue) && tok.name == "" {
+			continue
+		}
+
+		switch tok.kind {
+		case tokenFlag:
+			// bool flags do not take the following arg as their value
+			if flagIsBool(tree.chain, tok.name) {
+				continue
+			}
+			valueTok, ok := t.scanValue()
+			if !ok {
+				return tree, &parseError{
+					message: "Expected a following arg for flag " + tok.name + ", but it did not exist.",
+					sc:      tree.deepest(),
+				}
+			}
+			tok.value = valueTok.value
+			tree.tokens = append(tree.tokens, valueTok)
+		case tokenPositional:
+			position++
+			sc := tree.deepest()
+
+			// determine if this is a subcommand of the most specific subcommand
+			var next *Subcommand
+			for _, cmd := range sc.Subcommands {
+				if position == cmd.Position && (tok.value == cmd.Name || tok.value == cmd.ShortName) {
+					next = cmd
+					break
+				}
+			}
+			if next != nil {
+				debugPrint("Decending into positional subcommand", next.Name, "at relative position", position)
+				tok.kind = tokenSubcommand
+				tok.sc = next
+				tree.chain = append(tree.chain, next)
+				tree.chainIndexes = append(tree.chainIndexes, tok.index)
+				err = p.conflictWithBuiltins(next)
+				if err != nil {
+					return tree, err
+				}
+				position = 0
+				continue
+			}
+
+			// determine if this is a positional value of the most specific subcommand
+			for _, val := range sc.PositionalFlags {
+				if position == val.Position {
+					debugPrint("Found a positional value at relativePos:", position, "value:", tok.value)
+					tok.sc = sc
+					tok.positional = val
+					break
+				}
+			}
+			if tok.positional == nil && rejectUnexpected {
+				return tree, unexpectedPositionalError(sc, position, tok.value)
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// conflictWithBuiltins returns an error when help or version flags are used
+// by a subcommand while the parser has the built-in help and version flags
+// enabled
+func (p *Parser) conflictWithBuiltins(sc *Subcommand) error {
+	debugPrint("used subcommand", sc.Name, sc.ShortName)
+	if p.ShowHelpWithHFlag {
+		if err := sc.conflictWithBuiltinHelp(); err != nil {
+			return err
+		}
+	}
+	if p.ShowVersionWithVersionFlag {
+		return sc.conflictWithBuiltinVersion()
+	}
+	return nil
+}
+
+// unexpectedPositionalError describes a positional arg that matched neither
+// a subcommand nor a positional value.  When subcommands exist at the
+// position, they are suggested.
+func unexpectedPositionalError(sc *Subcommand, position int, value string) *parseError {
+	debugPrint("No positional at position", position)
+	for _, cmd := range sc.Subcommands {
+		if cmd.Position == position {
+			return &parseError{
+				message:  sc.Name + ": No subcommand or positional value found at position " + strconv.Itoa(position) + ".",
+				sc:       sc,
+				position: position,
+				suggest:  true,
+			}
+		}
+	}
+	return &parseError{
+		message:  "Unexpected argument: " + value,
+		sc:       sc,
+		position: position,
+	}
+}
+
+// exitBecauseOfParseError displays a useful message for an error found while
+// building the parse tree, then exits
+func (p *Parser) exitBecauseOfParseError(err *parseError) {
+	// if there is a subcommand here but it was not specified, display them all
+	// as a suggestion to the user before exiting.
+	if err.suggest {
+		fmt.Fprintln(p.errOutput(), p.Theme.colorizeError(p.errOutput(), err.message))
+		var output string
+		for _, cmd := range err.sc.Subcommands {
+			if cmd.Hidden {
+				continue
+			}
+			output = output + " " + cmd.Name
+		}
+		// if there are available subcommands, let the user know
+		if len(output) > 0 {
+			output = strings.TrimLeft(output, " ")
+			fmt.Fprintln(p.errOutput(), "Available subcommands:", output)
+		}
+		exitOrPanic(2)
+		return
+	}
+
+	// otherwise throw an error (display Help if necessary)
+	p.ShowHelpWithMessage(err.message)
+	exitOrPanic(2)
+}
+
+// resolveParseTree resolves every flag token in the parse tree against the
+// chain of subcommands used.  Flags of parent subcommands are used when they
+// are not local, as described by resolveFlagInChain.  Local flags can only be used with their own subcommand,
+// and with StrictFlagPositions flags must follow the subcommand that defines
+// them.  Flags that can not be resolved, including flags without a name, are
+// left for findUnknownArgs to report.
+func (p *Parser) resolveParseTree(tree *parseTree) error {
+	for _, tok := range tree.tokens {
+		if (tok.kind != tokenFlag && tok.kind != tokenFlagWithValue) || tok.name == "" {
+			continue
+		}
+		sc, f := resolveFlagInChain(tree.chain, tok.name)
+		if f == nil {
+			for _, parent := range tree.chain {
+				if local := parent.lookupFlag(tok.name); local != nil && local.Scope == ScopeLocal {
+					return errors.New("Flag " + tok.name + " can only be used with subcommand " + parent.Name + " and not with subcommand " + tree.deepest().Name)
+				}
+			}
+			continue
+		}
+		if p.StrictFlagPositions && tok.index < tree.enteredAt(sc) {
+			return errors.New("Flag " + tok.name + " must be placed after subcommand " + sc.Name)
+		}
+		tok.sc = sc
+		tok.flag = f
+	}
+	return nil
+}
+
+// flagTokenValue returns the raw value supplied for a resolved flag token and
+// the value to assign.  A flag without a value is a bool flag being set.
+// Values are loaded from files when allowed, in which case the raw value is
+// the @ reference.
+func (p *Parser) flagTokenValue(tree *parseTree, tok *token) (string, string, error) {
+	raw := tok.value
+	if tok.kind == tokenFlag && tok.flag.isBoolFlag() {
+		raw = "true"
+	}
+	if tree.fileValues == nil {
+		tree.fileValues = make(map[string]string)
+	}
+	value, err := p.loadFileValue(tok.flag, tok.name, raw, tree.fileValues)
+	return raw, value, err
+}
+
+// applyParseTree records which subcommands were used, resolves the flags in
+// the parse tree and assigns the values of flags and positional values to
+// the parser's subcommands in the order they were supplied.
+func (p *Parser) applyParseTree(tree *parseTree) error {
+	for _, sc := range tree.chain {
+		sc.Used = true
+		if len(sc.Name) > 0 {
+			sc.addParsedPositionalValue(sc.Name)
+		}
+		if len(sc.ShortName) > 0 {
+			sc.addParsedPositionalValue(sc.ShortName)
+		}
+	}
+
+	err := p.resolveParseTree(tree)
+	if err != nil {
+		return err
+	}
+
+	for _, tok := range tree.tokens {
+		switch tok.kind {
+		case tokenFlag, tokenFlagWithValue:
+			if tok.flag == nil {
+				continue
+			}
+			raw, value, err := p.flagTokenValue(tree, tok)
+			if err != nil {
+				return err
+			}
+			err = tok.flag.assignSuppliedValue(raw, value)
+			if err != nil {
+				return err
+			}
+			tok.flag.source = SourceArgs
+
+			// log all values parsed by the subcommand.  Bool flags without an
+			// explicit value are logged with a blank value
+			tok.sc.addParsedFlag(tok.name, tok.value)
+		case tokenPositional:
+			if tok.positional == nil {
+				continue
+			}
+			// set original value for help output
+			tok.positional.defaultValue = *tok.positional.AssignmentVar
+			*tok.positional.AssignmentVar = tok.value
+			tok.positional.Found = true
+			tok.sc.addParsedPositionalValue(tok.value)
+		case tokenTrailing:
+			p.TrailingArguments = append(p.TrailingArguments, tok.value)
+		}
+	}
+	return nil
+}
+
+// findUnknownArgs returns every arg that was not used by the resolved parse
+// tree, exactly as it was supplied.  Flags that could not be resolved are
+// reported along with their values, as are positional args that matched
+// nothing.  Flags starting with 'test.' are ignored because they are
+// injected by go test.
+func findUnknownArgs(tree *parseTree) []string {
+	var unknown []string
+	for i, tok := range tree.tokens {
+		if tok.kind == tokenPositional && tok.positional == nil {
+			unknown = append(unknown, tok.arg)
+			continue
+		}
+		if tok.kind != tokenFlag && tok.kind != tokenFlagWithValue {
+			continue
+		}
+		if tok.flag != nil || strings.HasPrefix(tok.name, "test.") {
+			continue
+		}
+		unknown = append(unknown, tok.arg)
+		if i+1 < len(tree.tokens) && tree.tokens[i+1].kind == tokenFlagValue {
+			unknown = append(unknown, tree.tokens[i+1].arg)
+		}
+	}
+	return unknown
+}
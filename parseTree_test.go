@@ -0,0 +1,144 @@
+package flaggy
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseTreeTokens(t *testing.T) {
+	p := NewParser("testParseTreeTokens")
+	var verbose bool
+	var name, file string
+	var port int
+	p.Bool(&verbose, "v", "verbose", "verbose flag")
+	p.String(&name, "n", "name", "name flag")
+	server := NewSubcommand("server")
+	server.Int(&port, "p", "port", "port flag")
+	server.AddPositionalValue(&file, "file", 1, false, "file positional")
+	p.AttachSubcommand(server, 1)
+
+	args := []string{"-v", "--name", "server", "server", "--port=80", "server", "--", "-x", "y"}
+	tree, err := p.buildParseTree(args, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.applyParseTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []tokenKind{
+		tokenFlag, tokenFlag, tokenFlagValue, tokenSubcommand, tokenFlagWithValue,
+		tokenPositional, tokenTerminator, tokenTrailing, tokenTrailing,
+	}
+	if len(tree.tokens) != len(expected) {
+		t.Fatal("expected", len(expected), "tokens but found", len(tree.tokens))
+	}
+	for i, tok := range tree.tokens {
+		if tok.kind != expected[i] || tok.index != i || tok.arg != args[i] {
+			t.Fatal("token", i, "incorrect:", tok.kind, tok.index, tok.arg)
+		}
+	}
+
+	// the value of --name and the positional value both equal the name of the
+	// subcommand, but each arg is attributed by position
+	if name != "server" || file != "server" || port != 80 || !verbose {
+		t.Fatal("values parsed incorrectly", name, file, port, verbose)
+	}
+	if len(tree.chain) != 2 || tree.deepest() != server || tree.enteredAt(server) != 3 {
+		t.Fatal("subcommand chain incorrect", tree.chain, tree.chainIndexes)
+	}
+	if len(p.TrailingArguments) != 2 || p.TrailingArguments[0] != "-x" {
+		t.Fatal("trailing arguments incorrect", p.TrailingArguments)
+	}
+}
+
+// largeArgs creates n args made of repeated flags and flag values
+func largeArgs(n int) []string {
+	var args []string
+	for len(args) < n {
+		i := strconv.Itoa(len(args))
+		args = append(args, "--tag", "tag"+i, "-n="+i, "-v")
+	}
+	return args
+}
+
+// benchmarkParseArgs benchmarks parsing n args into a parser with a chain of
+// subcommands
+func benchmarkParseArgs(b *testing.B, n int) {
+	args := append([]string{"server", "start"}, largeArgs(n)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := NewParser("benchmarkParseArgs")
+		var tags []string
+		var number int
+		var verbose bool
+		p.StringSlice(&tags, "t", "tag", "tag flag")
+		server := NewSubcommand("server")
+		server.Int(&number, "n", "number", "number flag")
+		start := NewSubcommand("start")
+		start.Bool(&verbose, "v", "verbose", "verbose flag")
+		p.AttachSubcommand(server, 1)
+		server.AttachSubcommand(start, 1)
+		err := p.ParseArgs(args)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseArgs10(b *testing.B) {
+	benchmarkParseArgs(b, 10)
+}
+
+func BenchmarkParseArgs1000(b *testing.B) {
+	benchmarkParseArgs(b, 1000)
+}
+
+func BenchmarkParseArgs10000(b *testing.B) {
+	benchmarkParseArgs(b, 10000)
+}
+
+func TestParseTreeEmptyFlagNames(t *testing.T) {
+	// flags without a short name must not be matched by an empty name
+	p := NewParser("testParseTreeEmptyFlagNames")
+	p.ShowHelpOnUnexpected = false
+	var name string
+	var verbose bool
+	p.String(&name, "", "name", "name flag")
+	p.Bool(&verbose, "", "verbose", "verbose flag")
+
+	cases := []struct {
+		args     []string
+		unknown  []string
+		trailing []string
+	}{
+		{args: []string{"--="}, unknown: []string{"--="}},
+		{args: []string{"-=x"}, unknown: []string{"-=x"}},
+		{args: []string{"-", "value"}, unknown: []string{"-", "value"}},
+		{args: []string{"--"}},
+		{args: []string{"--", "--="}, trailing: []string{"--="}},
+	}
+	for _, c := range cases {
+		r, err := p.ParseArgsInto(c.args)
+		if err != nil {
+			t.Fatal(c.args, err)
+		}
+		if !reflect.DeepEqual(r.Unknown(), c.unknown) || !reflect.DeepEqual(r.TrailingArguments(), c.trailing) {
+			t.Fatal(c.args, "unexpected unknown or trailing args:", r.Unknown(), r.TrailingArguments())
+		}
+		if r.Changed("name") || r.Changed("verbose") {
+			t.Fatal(c.args, "an empty flag name set a flag")
+		}
+	}
+
+	// parsing ignores them when unexpected args are allowed
+	err := p.ParseArgs([]string{"--=", "-=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "" || verbose {
+		t.Fatal("an empty flag name set a flag:", name, verbose)
+	}
+}
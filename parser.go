@@ -1,11 +1,14 @@
 package flaggy
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"text/template"
 )
@@ -21,7 +24,17 @@ type Parser struct {
 	ShowHelpOnUnexpected       bool               // display help when an unexpected flag or subcommand is passed
 	TrailingArguments          []string           // everything after a -- is placed here
 	HelpTemplate               *template.Template // template for Help output
-	trailingArgumentsExtracted bool               // indicates that trailing args have been parsed and should not be appended again
+	Theme                      *Theme             // optional colors for help and error output
+	outWriter                  io.Writer          // where version output is written.  Defaults to os.Stdout
+	errWriter                  io.Writer          // where help and error output is written.  Defaults to os.Stderr
+	inReader                   io.Reader          // where values are read from, such as flag values of @-.  Defaults to os.Stdin
+	inputLock                  sync.Mutex         // serializes reads of input by concurrent parses
+	AllowFileValues            bool               // allow every flag to load its value from a file with @path or stdin with @-
+	MaxFileValueSize           int64              // the largest file value in bytes.  Defaults to DefaultMaxFileValueSize
+	ResponseFiles              bool               // expand @path arguments into the arguments contained in the file at path
+	SliceDefaults              SliceDefaultPolicy // how supplied values combine with slice flag defaults when flags do not set their own policy
+	StrictFlagPositions        bool               // only recognize flags placed after the subcommand that defines them
+	ForcePrompts               bool               // prompt for missing values in ParseContext even when input is not a terminal
 	parsed                     bool               // indicates this parser has parsed
 	subcommandContext          *Subcommand        // points to the most specific subcommand being used
 }
@@ -45,111 +58,139 @@ func NewParser(name string) *Parser {
 // is a low level issue converting flags to their proper type.  No error
 // is returned for invalid arguments or missing require subcommands.
 func (p *Parser) ParseArgs(args []string) error {
+	return p.parseArgs(context.Background(), args, false)
+}
+
+// parseArgs parses the passed args for ParseArgs and ParseContext.  Missing
+// values are asked for when prompt is set and input is interactive.
+func (p *Parser) parseArgs(ctx context.Context, args []string, prompt bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if p.parsed {
 		return errors.New("Parser.Parse() called twice on parser with name: " + " " + p.Name + " " + p.ShortName)
 	}
 	p.parsed = true
 
+	// replace any response file arguments with their contents
+	if p.ResponseFiles {
+		var err error
+		args, err = p.expandResponseFiles(args, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	// determine how every slice flag treats its default values, and forget
+	// the map keys set before this parse
+	for _, f := range collectAllNestedFlags(&p.Subcommand) {
+		f.applySliceDefaultPolicy(p.SliceDefaults)
+		f.mapKeysSet = nil
+	}
+
 	debugPrint("Kicking off parsing with args:", args)
-	err := p.parse(p, args, 0)
+	tree, err := p.buildParseTree(args, p.ShowHelpOnUnexpected)
+	p.subcommandContext = tree.deepest()
+	if tree.versionRequested {
+		p.ShowVersionAndExit()
+	}
+	if parseErr, ok := err.(*parseError); ok {
+		p.exitBecauseOfParseError(parseErr)
+		return err
+	}
+	if err != nil {
+		// flags conflicting with the built-in flags are a mistake of the
+		// program rather than its user, so help is not displayed
+		fmt.Fprintln(p.errOutput(), err)
+		exitOrPanic(1)
+		return err
+	}
+	err = p.applyParseTree(tree)
 	if err != nil {
 		return err
 	}
 
-	// if we are set to crash on unexpected args, look for those here TODO
+	// if help was requested and we should show help when h is passed,
+	if tree.helpRequested && p.ShowHelpWithHFlag {
+		p.ShowHelp()
+		exitOrPanic(0)
+	}
+
+	// ask for values that were not supplied when the user can answer
+	if prompt && p.interactive() {
+		err = p.promptForMissingValues(ctx, tree)
+		if err != nil {
+			return err
+		}
+	}
+
+	// find any positionals that were not used on subcommands that were
+	// found and throw help (unknown argument) in the global parse or subcommand
+	sc := tree.deepest()
+	for _, pv := range p.PositionalFlags {
+		if pv.Required && !pv.Found {
+			p.ShowHelpWithMessage("Required global positional variable " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
+			exitOrPanic(2)
+		}
+	}
+	for _, pv := range sc.PositionalFlags {
+		if pv.Required && !pv.Found {
+			p.ShowHelpWithMessage("Required positional of subcommand " + sc.Name + " named " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
+			exitOrPanic(2)
+		}
+	}
+
+	// if we are set to crash on unexpected args, look for those here
 	if p.ShowHelpOnUnexpected {
-		parsedValues := p.findAllParsedValues()
-		debugPrint("parsedValues:", parsedValues)
-		argsNotParsed := findArgsNotInParsedValues(args, parsedValues)
+		argsNotParsed := findUnknownArgs(tree)
 		if len(argsNotParsed) > 0 {
-			// flatten out unused args for our error message
-			var argsNotParsedFlat string
-			for _, a := range argsNotParsed {
-				argsNotParsedFlat = argsNotParsedFlat + " " + a
-			}
-			p.ShowHelpAndExit("Unknown arguments supplied: " + argsNotParsedFlat)
+			p.ShowHelpAndExit("Unknown arguments supplied: " + strings.Join(argsNotParsed, " "))
 		}
 	}
 
 	return nil
 }
 
-// findArgsNotInParsedValues finds arguments not used in parsed values.  The
-// incoming args should be in the order supplied by the user and should not
-// include the invoked binary, which is normally the first thing in os.Args.
-func findArgsNotInParsedValues(args []string, parsedValues []parsedValue) []string {
-	// DebugMode = true
-	// defer func() {
-	// 	DebugMode = false
-	// }()
-
-	var argsNotUsed []string
-	var skipNext bool
-	for _, a := range args {
-
-		// if the final argument (--) is seen, then we stop checking because all
-		// further values are trailing arguments.
-		if determineArgType(a) == argIsFinal {
-			return argsNotUsed
-		}
-
-		// allow for skipping the next arg when needed
-		if skipNext {
-			skipNext = false
-			continue
-		}
+// Lookup returns the flag with the specified short or long name.  A flag of
+// the parser with the default scope is found first.  Otherwise, the most
+// specific subcommand used while parsing is searched first, followed by the
+// flags that are not local of each of its parents up to the parser itself.
+// Nil is returned when no flag has the name.
+func (p *Parser) Lookup(name string) *Flag {
+	_, f := p.resolveFlag(name)
+	return f
+}
 
-		// strip flag slashes from incoming arguments so they match up with the
-		// keys from parsedValues.
-		arg := parseFlagToName(a)
+// Changed determines if the flag with the specified short or long name was
+// set while parsing.  Flags are found the same way as Lookup.
+func (p *Parser) Changed(name string) bool {
+	f := p.Lookup(name)
+	return f != nil && f.Changed()
+}
 
-		// skip args that start with 'test.' because they are injected with go test
-		debugPrint("flagsNotParsed: checking arg for test prefix:", arg)
-		if strings.HasPrefix(arg, "test.") {
-			debugPrint("skipping test. prefixed arg has test prefix:", arg)
-			continue
-		}
-		debugPrint("flagsNotParsed: flag is not a test. flag:", arg)
-
-		// indicates that we found this arg used in one of the parsed values. Used
-		// to indicate which values should be added to argsNotUsed.
-		var foundArgUsed bool
-
-		// search all args for a corresponding parsed value
-		for _, pv := range parsedValues {
-			// this argumenet was a key
-			// debugPrint(pv.Key, "==", arg)
-			debugPrint(pv.Key + "==" + arg + " || (" + strconv.FormatBool(pv.IsPositional) + " && " + pv.Value + " == " + arg + ")")
-			if pv.Key == arg || (pv.IsPositional && pv.Value == arg) {
-				debugPrint("Found matching parsed arg for " + pv.Key)
-				foundArgUsed = true // the arg was used in this parsedValues set
-				// if the value is not a positional value and the parsed value had a
-				// value that was not blank, we skip the next value in the argument list
-				if !pv.IsPositional && len(pv.Value) > 0 {
-					skipNext = true
-					break
-				}
-			}
-			// this prevents excessive parsed values from being checked after we find
-			// the arg used for the first time
-			if foundArgUsed {
+// activeSubcommands returns the parser's own subcommand followed by each
+// subcommand used while parsing, from least to most specific
+func (p *Parser) activeSubcommands() []*Subcommand {
+	chain := []*Subcommand{&p.Subcommand}
+	for current := &p.Subcommand; ; {
+		var next *Subcommand
+		for _, sc := range current.Subcommands {
+			if sc.Used {
+				next = sc
 				break
 			}
 		}
-
-		// if the arg was not used in any parsed values, then we add it to the slice
-		// of arguments not used
-		if !foundArgUsed {
-			argsNotUsed = append(argsNotUsed, arg)
+		if next == nil {
+			return chain
 		}
+		chain = append(chain, next)
+		current = next
 	}
-
-	return argsNotUsed
 }
 
 // ShowVersionAndExit shows the version of this parser
 func (p *Parser) ShowVersionAndExit() {
-	fmt.Println("Version:", p.Version)
+	fmt.Fprintln(p.stdOutput(), "Version:", p.Version)
 	exitOrPanic(0)
 }
 
@@ -157,7 +198,7 @@ func (p *Parser) ShowVersionAndExit() {
 // Help.
 func (p *Parser) SetHelpTemplate(tmpl string) error {
 	var err error
-	p.HelpTemplate = template.New(helpFlagLongName)
+	p.HelpTemplate = template.New(helpFlagLongName).Funcs(p.Theme.templateFuncs(false))
 	p.HelpTemplate, err = p.HelpTemplate.Parse(tmpl)
 	if err != nil {
 		return err
@@ -187,13 +228,27 @@ func (p *Parser) ShowHelpAndExit(message string) {
 // displayed to the user.
 func (p *Parser) ShowHelpWithMessage(message string) {
 
+	err := p.writeHelp(p.errOutput(), message)
+	if err != nil {
+		fmt.Fprintln(p.errOutput(), "Error rendering Help template:", err)
+	}
+}
+
+// writeHelp renders the Help template for this parser to the supplied writer,
+// colorizing the output with the parser's Theme when the writer supports it.
+func (p *Parser) writeHelp(w io.Writer, message string) error {
 	// create a new Help values template and extract values into it
 	help := Help{}
 	help.ExtractValues(p, message)
-	err := p.HelpTemplate.Execute(os.Stderr, help)
+
+	// choose the template funcs on a copy so that help can be written to
+	// several writers at once without changing the parser's template
+	tmpl, err := p.HelpTemplate.Clone()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error rendering Help template:", err)
+		return err
 	}
+	tmpl.Funcs(p.Theme.templateFuncs(p.Theme.enabled(w)))
+	return tmpl.Execute(w, help)
 }
 
 // DisableShowVersionWithVersion disables the showing of version information
@@ -201,3 +256,45 @@ func (p *Parser) ShowHelpWithMessage(message string) {
 func (p *Parser) DisableShowVersionWithVersion() {
 	p.ShowVersionWithVersionFlag = false
 }
+
+// SetOutput sets the writer that version output is written to.  Passing nil
+// restores the default of os.Stdout.
+func (p *Parser) SetOutput(w io.Writer) {
+	p.outWriter = w
+}
+
+// SetErrOutput sets the writer that help, conflict and error messages are
+// written to.  Passing nil restores the default of os.Stderr.
+func (p *Parser) SetErrOutput(w io.Writer) {
+	p.errWriter = w
+}
+
+// SetInput sets the reader that values such as @- flag values are read from.
+// Passing nil restores the default of os.Stdin.
+func (p *Parser) SetInput(r io.Reader) {
+	p.inReader = r
+}
+
+// stdOutput returns the writer used for normal output
+func (p *Parser) stdOutput() io.Writer {
+	if p.outWriter == nil {
+		return os.Stdout
+	}
+	return p.outWriter
+}
+
+// errOutput returns the writer used for help and error output
+func (p *Parser) errOutput() io.Writer {
+	if p.errWriter == nil {
+		return os.Stderr
+	}
+	return p.errWriter
+}
+
+// inputReader returns the reader used for input
+func (p *Parser) inputReader() io.Reader {
+	if p.inReader == nil {
+		return os.Stdin
+	}
+	return p.inReader
+}
@@ -1,6 +1,11 @@
 package flaggy
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestDoubleParse(t *testing.T) {
 	ResetParser()
@@ -32,31 +37,159 @@ func TestDisableShowVersionFlag(t *testing.T) {
 	}
 }
 
-func TestFindArgsNotInParsedValues(t *testing.T) {
+func TestFindUnknownArgs(t *testing.T) {
 	t.Parallel()
 
+	parse := func(args []string) []string {
+		p := NewParser("testFindUnknownArgs")
+		p.ShowHelpOnUnexpected = false
+		var name, positional string
+		p.String(&name, "n", "name", "name flag")
+		p.AddPositionalValue(&positional, "positional", 1, false, "positional value")
+		tree, err := p.buildParseTree(args, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.applyParseTree(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findUnknownArgs(tree)
+	}
+
 	// ensure all 'test.' values are skipped
-	args := []string{"test.timeout=10s", "test.v=true"}
-	parsedValues := []parsedValue{}
-	unusedArgs := findArgsNotInParsedValues(args, parsedValues)
+	unusedArgs := parse([]string{"-test.timeout=10s", "-test.v", "true"})
 	if len(unusedArgs) > 0 {
-		t.Fatal("Found 'test.' args as unused when they should be ignored")
+		t.Fatal("Found 'test.' args as unused when they should be ignored", unusedArgs)
+	}
+
+	// ensure unknown flags are found along with their values, even when the
+	// same value was used elsewhere
+	unusedArgs = parse([]string{"--name", "testing", "testing", "--unusedFlag", "testing"})
+	if strings.Join(unusedArgs, " ") != "--unusedFlag testing" {
+		t.Fatal("Invalid unused args found:", unusedArgs)
+	}
+	unusedArgs = parse([]string{"--unusedFlag=testing", "testing"})
+	if strings.Join(unusedArgs, " ") != "--unusedFlag=testing" {
+		t.Fatal("Invalid unused args found:", unusedArgs)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	PanicInsteadOfExit = true
+
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	p := NewParser("testSetOutput")
+	p.Version = "1.2.3"
+	p.SetOutput(&out)
+	p.SetErrOutput(&errOut)
+
+	// help is written to the error output
+	p.ShowHelpWithMessage("test message")
+	if !strings.Contains(errOut.String(), "test message") {
+		t.Fatal("help was not written to the error output:", errOut.String())
+	}
+	if out.Len() > 0 {
+		t.Fatal("help was written to the standard output:", out.String())
 	}
 
-	// ensure regular values are not skipped
-	parsedValues = []parsedValue{
-		parsedValue{
-			Key:   "flaggy",
-			Value: "testing",
-		},
+	// version is written to the standard output
+	func() {
+		defer func() {
+			recover()
+		}()
+		p.ShowVersionAndExit()
+	}()
+	if out.String() != "Version: 1.2.3\n" {
+		t.Fatal("version was not written to the standard output:", out.String())
+	}
+}
+
+func TestChangedAfterInvalidValue(t *testing.T) {
+	p := NewParser("testChangedAfterInvalidValue")
+	AddP(&p.Subcommand, "p", "port", 8080, "port flag")
+	found, err := p.SetValueForKey("port", "many")
+	if !found || err == nil {
+		t.Fatal("expected an error setting an invalid port")
+	}
+	if p.Changed("port") || p.Lookup("port").Source() != SourceDefault {
+		t.Fatal("an invalid value changed the source of the flag")
+	}
+}
+
+func TestSetValueForKeyFrom(t *testing.T) {
+	p := NewParser("testSetValueForKeyFrom")
+	AddP(&p.Subcommand, "p", "port", 8080, "port flag")
+	AddP(&p.Subcommand, "r", "region", "us", "region flag")
+	AddP(&p.Subcommand, "o", "output", "text", "output flag")
+
+	// values set by loaders are changed, but were not supplied on the
+	// command line
+	_, err := p.SetValueForKeyFrom("port", "9090", SourceEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.SetValueForKey("region", "eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.ParseArgs([]string{"-o", "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]ValueSource{"port": SourceEnv, "region": SourceConfig, "output": SourceArgs}
+	for name, source := range sources {
+		if !p.Changed(name) || p.Lookup(name).Source() != source {
+			t.Fatal("unexpected source of", name, p.Lookup(name).Source())
+		}
+	}
+	if SourceEnv.String() != "env" || SourceConfig.String() != "config" {
+		t.Fatal("unexpected source names", SourceEnv, SourceConfig)
+	}
+}
+
+func TestLookupAndChanged(t *testing.T) {
+	p := NewParser("testLookupAndChanged")
+	timeout := AddP[time.Duration](&p.Subcommand, "t", "timeout", 0, "timeout flag")
+	AddP(&p.Subcommand, "o", "output", "text", "output flag")
+	p.SetFlagScope(ScopeLocal, "output")
+	serve := NewSubcommand("serve")
+	port := AddP(serve, "p", "port", 8080, "port flag")
+	AddP(serve, "o", "output", "json", "serve output flag")
+	p.AttachSubcommand(serve, 1)
+	unused := NewSubcommand("unused")
+	AddP(unused, "v", "verbose", false, "verbose flag")
+	p.AttachSubcommand(unused, 1)
+
+	err := p.ParseArgs([]string{"-t", "5s", "serve", "--port=9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout.String() != "5s" || *port != 9090 {
+		t.Fatal("flags parsed incorrectly", timeout, *port)
+	}
+
+	if !p.Changed("timeout") || !p.Changed("port") || p.Changed("output") {
+		t.Fatal("parser changed flags incorrect")
+	}
+	if !serve.Changed("p") || serve.Changed("timeout") {
+		t.Fatal("subcommand changed flags incorrect")
+	}
+
+	f := p.Lookup("port")
+	if f == nil || f.Source() != SourceArgs || f.RawValue() != "9090" || f.DefaultValue() != "8080" {
+		t.Fatal("port flag lookup incorrect", f)
+	}
+	f = p.Lookup("o")
+	if f == nil || f.DefaultValue() != "json" || f.Source() != SourceDefault || f.Source().String() != "default" {
+		t.Fatal("lookup did not prefer the most specific subcommand", f)
 	}
-	args = []string{"flaggy", "testing", "unusedFlag"}
-	unusedArgs = findArgsNotInParsedValues(args, parsedValues)
-	t.Log(unusedArgs)
-	if len(unusedArgs) == 0 {
-		t.Fatal("Found no args as unused when --flaggy=testing should have been detected")
+	if p.Lookup("verbose") != nil {
+		t.Fatal("lookup found a flag on an unused subcommand")
 	}
-	if len(unusedArgs) != 1 {
-		t.Fatal("Invalid number of unused args found.  Expected 1 but found", len(unusedArgs))
+	if p.Lookup("missing") != nil {
+		t.Fatal("lookup found a missing flag")
 	}
 }
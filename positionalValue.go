@@ -10,5 +10,6 @@ type PositionalValue struct {
 	Required      bool    // this subcommand must always be specified
 	Found         bool    // was this positional found during parsing?
 	Hidden        bool    // indicates this positional value should be hidden from help
+	Prompt        *Prompt // asks for this positional value on a terminal when it is required but not supplied to ParseContext
 	defaultValue  string  // used for help output
 }
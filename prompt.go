@@ -0,0 +1,318 @@
+package flaggy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Prompt describes how ParseContext asks for a value that was not supplied.
+// Set it on a Flag or PositionalValue to ask for the value on a terminal
+// instead of leaving the default or displaying an error.
+type Prompt struct {
+	Message string   // the question displayed.  Defaults to the description, or the name when there is no description
+	Secret  bool     // hide the value as it is typed, such as for passwords
+	Choices []string // the values to choose from, displayed as a numbered menu
+}
+
+// ParseContext parses the passed args like ParseArgs, but asks for values
+// that were not supplied when input is a terminal or ForcePrompts is set.
+// Flags with a Prompt that can be used with the subcommands used are asked
+// for when they were not supplied, as are required positional values with a
+// Prompt of the parser and the most specific subcommand used.  When input is
+// not interactive, parsing continues like ParseArgs, so missing required
+// positional values display help and exit.
+//
+// The error from ctx is returned when it is done before parsing starts or
+// before an answer is read.  Waiting for an answer stops as soon as ctx is
+// done when input supports read deadlines, such as the controlling terminal
+// on unix systems, an os.Pipe or a net.Conn.  Other input is read until a line is entered,
+// and ctx is checked before each prompt.
+func (p *Parser) ParseContext(ctx context.Context, args []string) error {
+	return p.parseArgs(ctx, args, true)
+}
+
+// interactive determines if missing values can be asked for
+func (p *Parser) interactive() bool {
+	return p.ForcePrompts || isTerminal(p.inputReader())
+}
+
+// promptForMissingValues asks for the values of flags and required positional
+// values that have a Prompt and were not supplied, from the least to the most
+// specific subcommand used
+func (p *Parser) promptForMissingValues(ctx context.Context, tree *parseTree) error {
+	for i, sc := range tree.chain {
+		deepest := i == len(tree.chain)-1
+		for _, f := range sc.Flags {
+			if f.Prompt == nil || f.Changed() || (f.Scope == ScopeLocal && !deepest) {
+				continue
+			}
+			defaultValue, err := f.returnAssignmentVarValueAsString()
+			if err != nil {
+				return err
+			}
+			value, entered, err := p.prompt(ctx, f.Prompt, f.Description, flagDisplayName(f), defaultValue, false)
+			if err != nil {
+				return err
+			}
+			if !entered {
+				continue
+			}
+			err = f.identifyAndAssignValue(value)
+			if err != nil {
+				return err
+			}
+			f.source = SourcePrompt
+		}
+
+		// positional values are only checked on the parser and the most
+		// specific subcommand, like required positional values
+		if i != 0 && !deepest {
+			continue
+		}
+		for _, pv := range sc.PositionalFlags {
+			if pv.Prompt == nil || !pv.Required || pv.Found {
+				continue
+			}
+			value, entered, err := p.prompt(ctx, pv.Prompt, pv.Description, pv.Name, *pv.AssignmentVar, true)
+			if err != nil {
+				return err
+			}
+			if !entered {
+				value = *pv.AssignmentVar
+			}
+			pv.defaultValue = *pv.AssignmentVar
+			*pv.AssignmentVar = value
+			pv.Found = true
+			sc.addParsedPositionalValue(value)
+		}
+	}
+	return nil
+}
+
+// flagDisplayName returns the name of a flag as it is typed, such as --port
+func flagDisplayName(f *Flag) string {
+	if f.LongName != "" {
+		return "--" + f.LongName
+	}
+	return "-" + f.ShortName
+}
+
+// prompt asks for a value until a valid answer is entered.  False is
+// returned when nothing was entered and the default should be kept.  A value
+// is required when there is no default and required is set.
+func (p *Parser) prompt(ctx context.Context, prompt *Prompt, description string, name string, defaultValue string, required bool) (string, bool, error) {
+	w := p.errOutput()
+	label := prompt.Message
+	if label == "" {
+		label = description
+	}
+	if label == "" {
+		label = name
+	}
+
+	for {
+		if len(prompt.Choices) > 0 {
+			fmt.Fprintln(w, label)
+			for i, choice := range prompt.Choices {
+				fmt.Fprintln(w, "  "+strconv.Itoa(i+1)+") "+choice)
+			}
+			fmt.Fprint(w, "Choose a number")
+		} else {
+			fmt.Fprint(w, label)
+		}
+		if defaultValue != "" && !prompt.Secret {
+			fmt.Fprint(w, " ["+defaultValue+"]")
+		}
+		fmt.Fprint(w, ": ")
+
+		answer, err := p.readAnswer(ctx, prompt.Secret)
+		if err == io.EOF {
+			return "", false, errors.New("Input ended before a value was entered for " + name)
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		if answer == "" {
+			if defaultValue != "" || !required {
+				return "", false, nil
+			}
+			fmt.Fprintln(w, p.Theme.colorizeError(w, "A value is required for "+name+"."))
+			continue
+		}
+		if len(prompt.Choices) == 0 {
+			return answer, true, nil
+		}
+		if choice, ok := matchChoice(prompt.Choices, answer); ok {
+			return choice, true, nil
+		}
+		fmt.Fprintln(w, p.Theme.colorizeError(w, answer+" is not one of the choices."))
+	}
+}
+
+// matchChoice returns the choice selected by an answer, which can be either
+// the number displayed in the menu or the choice itself
+func matchChoice(choices []string, answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], true
+	}
+	for _, choice := range choices {
+		if choice == answer {
+			return choice, true
+		}
+	}
+	return "", false
+}
+
+// deadlineReader is input that can stop waiting to be read at a deadline,
+// such as a pipe or network connection
+type deadlineReader interface {
+	io.Reader
+	SetReadDeadline(t time.Time) error
+}
+
+// readAnswer reads a line of input, hiding it as it is typed when secret is
+// set.  The error from ctx is returned as soon as it is done when reads of the
+// input can be interrupted.  The read has always ended when readAnswer
+// returns, so no input is consumed afterwards.
+func (p *Parser) readAnswer(ctx context.Context, secret bool) (string, error) {
+	r := p.inputReader()
+	if secret {
+		restore, err := disableEcho(r)
+		if err != nil {
+			return "", err
+		}
+		defer restoreOnSignal(restore)()
+
+		// the newline typed was hidden too
+		defer fmt.Fprintln(p.errOutput())
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	dr, release, ok := interruptibleReader(r)
+	if !ok {
+		return readLine(r)
+	}
+	defer release()
+
+	// moving the deadline to the past when ctx is done stops the read
+	read := make(chan struct{})
+	interrupted := make(chan struct{})
+	go func() {
+		defer close(interrupted)
+		select {
+		case <-ctx.Done():
+			dr.SetReadDeadline(time.Unix(1, 0))
+		case <-read:
+		}
+	}()
+	line, err := readLine(dr)
+	close(read)
+	<-interrupted
+	dr.SetReadDeadline(time.Time{})
+
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return line, err
+}
+
+// restoreOnSignal calls restore when the program is interrupted or
+// terminated before the returned func is called, and then delivers the signal
+// again so that the program stops as it otherwise would have.  This keeps a
+// terminal from being left without echo.  The returned func stops watching
+// for signals and calls restore if it has not been called already.
+func restoreOnSignal(restore func()) func() {
+	var once sync.Once
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-stop:
+			// a signal may have arrived just before watching stopped
+			select {
+			case sig = <-signals:
+			default:
+				return
+			}
+		}
+		once.Do(restore)
+		signal.Stop(signals)
+		raiseSignal(sig)
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(stop)
+		<-stopped
+		once.Do(restore)
+	}
+}
+
+// raiseSignal delivers a signal to this process, or exits when signals can
+// not be delivered on this platform
+func raiseSignal(sig os.Signal) {
+	process, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = process.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// interruptibleReader returns a reader of the same input that supports read
+// deadlines, along with a func that releases it.  Terminals are opened again
+// so that they support deadlines where possible.  False is returned when reads
+// of the input can not be interrupted.
+func interruptibleReader(r io.Reader) (deadlineReader, func(), bool) {
+	if dr, ok := r.(deadlineReader); ok && dr.SetReadDeadline(time.Time{}) == nil {
+		return dr, func() {}, true
+	}
+	if f, ok := r.(*os.File); ok {
+		interruptible, release, ok := interruptibleFile(f)
+		if ok {
+			return interruptible, release, true
+		}
+	}
+	return nil, nil, false
+}
+
+// readLine reads a single line from the reader without the trailing newline.
+// Input is read one byte at a time so that nothing after the line is
+// consumed.  io.EOF is returned when input ends before anything is read.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
@@ -0,0 +1,144 @@
+package flaggy_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/integrii/flaggy"
+)
+
+// TestSecretPromptRestoresTerminal runs a secret prompt in a child process
+// whose controlling terminal is a pseudo terminal, and checks that the
+// terminal echoes and blocks on reads again once the prompt is cancelled or
+// the child is interrupted
+func TestSecretPromptRestoresTerminal(t *testing.T) {
+	if mode := os.Getenv("FLAGGY_SECRET_PROMPT"); mode != "" {
+		runSecretPrompt(mode)
+		return
+	}
+
+	// other tests replace os.Args, so find the test binary another way
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []string{"cancel", "interrupt"} {
+		master, slave, err := openPseudoTerminal()
+		if err != nil {
+			t.Skip("pseudo terminals are not available:", err)
+		}
+		defer master.Close()
+		defer slave.Close()
+
+		stderr := &bytes.Buffer{}
+		cmd := exec.Command(executable, "-test.run=^TestSecretPromptRestoresTerminal$")
+		cmd.Env = append(os.Environ(), "FLAGGY_SECRET_PROMPT="+mode)
+		cmd.Stdin = slave
+		cmd.Stderr = stderr
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+		err = cmd.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		timer := time.AfterFunc(10*time.Second, func() {
+			cmd.Process.Kill()
+		})
+
+		if mode == "interrupt" {
+			// wait for the prompt to hide input before interrupting it
+			for i := 0; i < 500 && terminalEchoes(t, slave); i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			cmd.Process.Signal(os.Interrupt)
+		}
+		err = cmd.Wait()
+		if !timer.Stop() {
+			t.Fatal("the prompt was not stopped when", mode+"ed", stderr.String())
+		}
+		if mode == "cancel" && err != nil {
+			t.Fatal("the prompt failed when cancelled:", err, stderr.String())
+		}
+
+		if !terminalEchoes(t, slave) {
+			t.Fatal("the terminal does not echo after the prompt was", mode+"ed")
+		}
+		flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, slave.Fd(), syscall.F_GETFL, 0)
+		if errno != 0 {
+			t.Fatal(errno)
+		}
+		if flags&syscall.O_NONBLOCK != 0 {
+			t.Fatal("the terminal is non-blocking after the prompt was", mode+"ed")
+		}
+	}
+}
+
+// runSecretPrompt asks for a secret on the controlling terminal in the child
+// process, until it is cancelled or interrupted
+func runSecretPrompt(mode string) {
+	p := flaggy.NewParser("prompter")
+	flaggy.AddP(&p.Subcommand, "p", "password", "", "The password")
+	p.Lookup("password").Prompt = &flaggy.Prompt{Secret: true}
+	timeout := 100 * time.Millisecond
+	if mode == "interrupt" {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := p.ParseContext(ctx, nil)
+	if err != context.DeadlineExceeded {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// openPseudoTerminal opens a new pseudo terminal.  The side used as a
+// terminal is opened without making it non-blocking, so that the blocking
+// mode left by the child process can be checked.
+func openPseudoTerminal() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := master.SyscallConn()
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	var unlock int32
+	var number uint32
+	var errno syscall.Errno
+	conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+		if errno == 0 {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number)))
+		}
+	})
+	if errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+	name := "/dev/pts/" + strconv.Itoa(int(number))
+	fd, err := syscall.Open(name, syscall.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, os.NewFile(uintptr(fd), name), nil
+}
+
+// terminalEchoes determines if the terminal displays what is typed into it
+func terminalEchoes(t *testing.T, f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	return termios.Lflag&syscall.ECHO != 0
+}
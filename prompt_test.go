@@ -0,0 +1,165 @@
+package flaggy_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/integrii/flaggy"
+)
+
+// newPromptParser creates a parser that prompts with the supplied input, and
+// returns the buffer prompts are written to
+func newPromptParser(input string) (*flaggy.Parser, *bytes.Buffer) {
+	p := flaggy.NewParser("prompter")
+	p.ForcePrompts = true
+	p.SetInput(strings.NewReader(input))
+	out := &bytes.Buffer{}
+	p.SetErrOutput(out)
+	return p, out
+}
+
+func TestParseContextPrompts(t *testing.T) {
+	p, out := newPromptParser("alice\n\nnope\n2\nhunter2\n")
+	user := flaggy.AddP(&p.Subcommand, "u", "user", "", "The user to log in as")
+	region := flaggy.AddP(&p.Subcommand, "r", "region", "us", "The region")
+	format := flaggy.AddP(&p.Subcommand, "f", "format", "json", "The output format")
+	password := flaggy.AddP(&p.Subcommand, "p", "password", "default-secret", "The password")
+	supplied := flaggy.AddP(&p.Subcommand, "s", "supplied", "", "A supplied flag")
+	p.Lookup("user").Prompt = &flaggy.Prompt{}
+	p.Lookup("region").Prompt = &flaggy.Prompt{Message: "Which region?"}
+	p.Lookup("format").Prompt = &flaggy.Prompt{Choices: []string{"json", "yaml"}}
+	p.Lookup("password").Prompt = &flaggy.Prompt{Secret: true}
+	p.Lookup("supplied").Prompt = &flaggy.Prompt{}
+
+	err := p.ParseContext(context.Background(), []string{"-s", "given"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *user != "alice" || *region != "us" || *format != "yaml" || *password != "hunter2" || *supplied != "given" {
+		t.Fatal("unexpected prompted values:", *user, *region, *format, *password, *supplied)
+	}
+	if p.Lookup("user").Source() != flaggy.SourcePrompt || p.Lookup("region").Source() != flaggy.SourceDefault {
+		t.Fatal("unexpected sources:", p.Lookup("user").Source(), p.Lookup("region").Source())
+	}
+
+	output := out.String()
+	for _, expected := range []string{"The user to log in as: ", "Which region? [us]: ", "  2) yaml", "nope is not one of the choices.", "The password: "} {
+		if !strings.Contains(output, expected) {
+			t.Fatal("expected prompt output to contain", expected, "but got", output)
+		}
+	}
+	if strings.Contains(output, "default-secret") || strings.Contains(output, "A supplied flag") {
+		t.Fatal("prompt output displayed a secret default or a supplied flag:", output)
+	}
+}
+
+func TestParseContextPromptsForPositionals(t *testing.T) {
+	p, out := newPromptParser("\nweb\n")
+	deploy := flaggy.NewSubcommand("deploy")
+	var service string
+	deploy.AddPositionalValue(&service, "service", 1, true, "The service to deploy")
+	deploy.PositionalFlags[0].Prompt = &flaggy.Prompt{}
+	p.AttachSubcommand(deploy, 1)
+
+	err := p.ParseContext(context.Background(), []string{"deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service != "web" || !deploy.PositionalFlags[0].Found {
+		t.Fatal("positional value not prompted for:", service)
+	}
+	if !strings.Contains(out.String(), "A value is required for service.") {
+		t.Fatal("blank answer to a required value was accepted:", out.String())
+	}
+}
+
+func TestParseContextWithoutTerminal(t *testing.T) {
+	// without a terminal, missing required positional values display help
+	p, _ := newPromptParser("ignored\n")
+	p.ForcePrompts = false
+	var service string
+	p.AddPositionalValue(&service, "service", 1, true, "The service")
+	p.PositionalFlags[0].Prompt = &flaggy.Prompt{}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic for a missing required positional value")
+		}
+	}()
+	p.ParseContext(context.Background(), nil)
+}
+
+func TestParseContextWithNullInput(t *testing.T) {
+	// /dev/null is a character device but not a terminal, so nothing is
+	// asked for
+	p, out := newPromptParser("")
+	p.ForcePrompts = false
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	p.SetInput(null)
+	var service string
+	p.AddPositionalValue(&service, "service", 1, true, "The service")
+	p.PositionalFlags[0].Prompt = &flaggy.Prompt{}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic for a missing required positional value")
+		}
+		if strings.Contains(out.String(), "The service: ") {
+			t.Fatal("prompted with input that is not a terminal:", out.String())
+		}
+	}()
+	p.ParseContext(context.Background(), nil)
+}
+
+func TestParseContextCancellation(t *testing.T) {
+	// a context that is already done stops parsing before it starts
+	p, _ := newPromptParser("")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.ParseContext(ctx, nil); err != context.Canceled {
+		t.Fatal("expected the context error but got", err)
+	}
+
+	// a context done while waiting for an answer stops the prompt, without
+	// leaving a read behind that consumes later input
+	p, _ = newPromptParser("")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if r.SetReadDeadline(time.Time{}) != nil {
+		t.Skip("pipes do not support read deadlines on this platform")
+	}
+	p.SetInput(r)
+	flaggy.AddP(&p.Subcommand, "u", "user", "", "The user")
+	p.Lookup("user").Prompt = &flaggy.Prompt{}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.ParseContext(ctx, nil); err != context.DeadlineExceeded {
+		t.Fatal("expected the context error but got", err)
+	}
+	w.Write([]byte("later\n"))
+	b := make([]byte, 6)
+	if _, err := io.ReadFull(r, b); err != nil || string(b) != "later\n" {
+		t.Fatal("input written after the prompt was stopped was consumed:", string(b), err)
+	}
+
+	// input that ends before an answer is an error
+	p, _ = newPromptParser("")
+	flaggy.AddP(&p.Subcommand, "u", "user", "", "The user")
+	p.Lookup("user").Prompt = &flaggy.Prompt{}
+	if err := p.ParseContext(context.Background(), nil); err == nil {
+		t.Fatal("expected an error when input ends")
+	}
+}
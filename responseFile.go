@@ -0,0 +1,141 @@
+package flaggy
+
+import (
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxResponseFileDepth is the number of response files that may be nested
+// within each other before expansion stops with an error
+const maxResponseFileDepth = 10
+
+// expandResponseFiles replaces every @path argument with the arguments
+// contained in the file at path.  Arguments that are the value of a preceding
+// flag and arguments after -- are left alone.  Response files may reference
+// other response files up to maxResponseFileDepth deep.
+func (p *Parser) expandResponseFiles(args []string, depth int) ([]string, error) {
+	if depth > maxResponseFileDepth {
+		return nil, errors.New("Response files nested more than the limit of " + strconv.Itoa(maxResponseFileDepth) + " deep")
+	}
+
+	var expanded []string
+	var isFlagValue bool
+	for i, a := range args {
+
+		// everything after -- is a trailing argument
+		if determineArgType(a) == argIsFinal {
+			return append(expanded, args[i:]...), nil
+		}
+
+		// values of flags are not response files
+		if isFlagValue {
+			isFlagValue = false
+			expanded = append(expanded, a)
+			continue
+		}
+		if determineArgType(a) == argIsFlagWithSpace {
+			isFlagValue = !flagIsBool([]*Subcommand{&p.Subcommand}, parseFlagToName(a))
+			expanded = append(expanded, a)
+			continue
+		}
+
+		if !strings.HasPrefix(a, fileValuePrefix) || a == fileValuePrefix {
+			expanded = append(expanded, a)
+			continue
+		}
+		path := strings.TrimPrefix(a, fileValuePrefix)
+
+		// an escaped @ is a literal argument
+		if strings.HasPrefix(path, fileValuePrefix) {
+			expanded = append(expanded, path)
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("Unable to read response file: " + err.Error())
+		}
+		fileArgs, err := tokenizeResponseFile(string(contents))
+		if err != nil {
+			return nil, errors.New("Unable to parse response file " + path + ": " + err.Error())
+		}
+		fileArgs, err = p.expandResponseFiles(fileArgs, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, fileArgs...)
+	}
+
+	return expanded, nil
+}
+
+// tokenizeResponseFile splits the contents of a response file into arguments
+// the way a shell would.  Arguments are separated by whitespace and may be
+// quoted with single or double quotes.  A backslash escapes the next character
+// outside of single quotes.  A # at the start of an argument begins a comment
+// that runs to the end of the line.
+func tokenizeResponseFile(contents string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var inArg bool     // indicates that current holds an argument, even if blank
+	var quote rune     // the quote character currently open, if any
+	var escaped bool   // indicates the previous character was a backslash
+	var inComment bool // indicates a comment is being skipped
+	for _, r := range contents {
+		switch {
+		case inComment:
+			if r == '\n' {
+				inComment = false
+			}
+		case escaped:
+			escaped = false
+			// a backslash before a newline continues the line
+			if r != '\n' {
+				current.WriteRune(r)
+				inArg = true
+			}
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case r == '#' && !inArg:
+			inComment = true
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated " + string(quote) + " quote")
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,102 @@
+package flaggy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeResponseFile(t *testing.T) {
+	testCases := map[string][]string{
+		"":                              nil,
+		"-a b\n--c=d":                   {"-a", "b", "--c=d"},
+		"'single quoted' \"double\"":    {"single quoted", "double"},
+		"escaped\\ space \"a\\\"b\"":    {"escaped space", "a\"b"},
+		"# a comment\n-a # trailing\nb": {"-a", "b"},
+		"not#comment ''":                {"not#comment", ""},
+		"line\\\ncontinued":             {"linecontinued"},
+	}
+	for contents, expected := range testCases {
+		args, err := tokenizeResponseFile(contents)
+		if err != nil {
+			t.Fatal("error tokenizing", contents, err)
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Fatalf("tokenized %q as %q but expected %q", contents, args, expected)
+		}
+	}
+
+	for _, contents := range []string{"'unterminated", "\"unterminated", "trailing\\"} {
+		_, err := tokenizeResponseFile(contents)
+		if err == nil {
+			t.Fatal("expected an error tokenizing", contents)
+		}
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flaggy-response-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nested := filepath.Join(dir, "nested.txt")
+	err = ioutil.WriteFile(nested, []byte("--name 'from nested'"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := filepath.Join(dir, "outer.txt")
+	err = ioutil.WriteFile(outer, []byte("# build flags\n-v\n@"+nested+"\npositional\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verbose bool
+	var name string
+	var value string
+	var pos string
+	p := NewParser("testResponseFiles")
+	p.ResponseFiles = true
+	p.Bool(&verbose, "v", "verbose", "verbose flag")
+	p.String(&name, "n", "name", "name flag")
+	p.String(&value, "", "value", "value flag")
+	p.AddPositionalValue(&pos, "pos", 1, true, "positional value")
+	err = p.ParseArgs([]string{"@" + outer, "--value", "@notAResponseFile"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verbose {
+		t.Fatal("verbose flag was not set from the response file")
+	}
+	if name != "from nested" {
+		t.Fatal("name flag was not set from the nested response file:", name)
+	}
+	if pos != "positional" {
+		t.Fatal("positional value was not set from the response file:", pos)
+	}
+	if value != "@notAResponseFile" {
+		t.Fatal("flag value was expanded as a response file:", value)
+	}
+}
+
+func TestResponseFileRecursionLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flaggy-response-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	recursive := filepath.Join(dir, "recursive.txt")
+	err = ioutil.WriteFile(recursive, []byte("@"+recursive), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser("testResponseFileRecursionLimit")
+	p.ResponseFiles = true
+	err = p.ParseArgs([]string{"@" + recursive})
+	if err == nil {
+		t.Fatal("Expected an error from a recursive response file")
+	}
+}
@@ -0,0 +1,353 @@
+package flaggy
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// ParseResult holds the outcome of parsing one set of args with
+// ParseArgsInto.  It is not changed after it is returned, and values read
+// from it are copies.
+type ParseResult struct {
+	chain            []*Subcommand     // the parser and each subcommand used, from least to most specific
+	flags            map[*Flag]*Flag   // a copy of every flag that applies to the chain, holding its parsed value
+	positionals      map[string]string // the values of positional values supplied, by name
+	trailing         []string          // everything after a --
+	unknown          []string          // args that were not used, exactly as supplied
+	helpRequested    bool              // indicates -h or --help was supplied
+	versionRequested bool              // indicates --version was supplied
+}
+
+// ParseArgsInto parses the supplied args like ParseArgs, but returns the
+// values found in a ParseResult instead of assigning them.  The parser and
+// its subcommands, flags and positional values are not changed, so a single
+// parser can parse many sets of args, such as the lines entered in a shell or
+// the cases of a test table.  Flag values start as a copy of each flag's
+// current value.
+//
+// Nothing is displayed and the program never exits.  Requests for help or
+// the version are reported by the result, and args that were not used are
+// returned by Unknown instead of causing an error.  Flags that conflict with
+// the built-in help and version flags are returned as an error.
+//
+// ParseArgsInto is safe for concurrent use by many goroutines sharing one
+// parser, as long as the parser's definition is not changed and ParseArgs is
+// not called while they parse.  Each call writes values only into its own
+// result, which Assign can copy into variables owned by the caller.  Values
+// of @- are read from the parser's input by one call at a time, so input is
+// only available to the first call that reads it.  Use a parser created with
+// NewParser rather than DefaultParser, and do not change DebugMode while
+// parsing.
+func (p *Parser) ParseArgsInto(args []string) (*ParseResult, error) {
+	// replace any response file arguments with their contents
+	if p.ResponseFiles {
+		var err error
+		args, err = p.expandResponseFiles(args, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	debugPrint("Kicking off parsing into a result with args:", args)
+	tree, err := p.buildParseTree(args, false)
+	if err != nil {
+		return nil, err
+	}
+	err = p.resolveParseTree(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ParseResult{
+		chain:            tree.chain,
+		flags:            make(map[*Flag]*Flag),
+		positionals:      make(map[string]string),
+		helpRequested:    tree.helpRequested,
+		versionRequested: tree.versionRequested,
+	}
+	for i, sc := range tree.chain {
+		for _, f := range sc.Flags {
+			if f.Scope == ScopeLocal && i != len(tree.chain)-1 {
+				continue
+			}
+			r.flags[f] = cloneFlag(f, p.SliceDefaults)
+		}
+	}
+
+	for _, tok := range tree.tokens {
+		switch tok.kind {
+		case tokenFlag, tokenFlagWithValue:
+			if tok.flag == nil {
+				continue
+			}
+			raw, value, err := p.flagTokenValue(tree, tok)
+			if err != nil {
+				return nil, err
+			}
+			f := r.flags[tok.flag]
+			err = f.assignSuppliedValue(raw, value)
+			if err != nil {
+				return nil, err
+			}
+			f.source = SourceArgs
+		case tokenPositional:
+			if tok.positional != nil {
+				r.positionals[tok.positional.Name] = tok.value
+			}
+		case tokenTrailing:
+			r.trailing = append(r.trailing, tok.value)
+		}
+	}
+	r.unknown = findUnknownArgs(tree)
+
+	// required positional values only matter when help was not requested
+	if r.helpRequested || r.versionRequested {
+		return r, nil
+	}
+	for i, sc := range tree.chain {
+		if i != 0 && i != len(tree.chain)-1 {
+			continue
+		}
+		for _, pv := range sc.PositionalFlags {
+			if _, found := r.positionals[pv.Name]; pv.Required && !found {
+				if i == 0 {
+					return nil, errors.New("Required global positional variable " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
+				}
+				return nil, errors.New("Required positional of subcommand " + sc.Name + " named " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
+			}
+		}
+	}
+	return r, nil
+}
+
+// cloneFlag copies a flag with a new AssignmentVar holding a copy of the
+// flag's current value, so that values can be assigned to the copy without
+// changing the original flag
+func cloneFlag(f *Flag, parserPolicy SliceDefaultPolicy) *Flag {
+	clone := *f
+	assignmentVar := reflect.New(reflect.TypeOf(f.AssignmentVar).Elem())
+	assignmentVar.Elem().Set(copyValue(reflect.ValueOf(f.AssignmentVar).Elem()))
+	clone.AssignmentVar = assignmentVar.Interface()
+	clone.rawValue = ""
+	clone.source = SourceDefault
+	clone.mapKeysSet = nil
+	clone.defaultCleared = false
+	clone.applySliceDefaultPolicy(parserPolicy)
+	return &clone
+}
+
+// copyValue returns a copy of the supplied value that does not share the
+// contents of slices or maps
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return copied
+	}
+	return v
+}
+
+// exportedTypes maps the unexported types that some built-in flags store
+// their values as to the types their constructors accept
+var exportedTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(byteSize(0)):          reflect.TypeOf(uint64(0)),
+	reflect.TypeOf(byteSizeSlice{}):      reflect.TypeOf([]uint64{}),
+	reflect.TypeOf(percentage(0)):        reflect.TypeOf(float64(0)),
+	reflect.TypeOf(percentageSlice{}):    reflect.TypeOf([]float64{}),
+	reflect.TypeOf(filePath("")):         reflect.TypeOf(""),
+	reflect.TypeOf(existingFilePath("")): reflect.TypeOf(""),
+	reflect.TypeOf(existingDirPath("")):  reflect.TypeOf(""),
+	reflect.TypeOf(outputFilePath("")):   reflect.TypeOf(""),
+	reflect.TypeOf(hexBytes{}):           reflect.TypeOf([]byte{}),
+	reflect.TypeOf(base64Bytes{}):        reflect.TypeOf([]byte{}),
+	reflect.TypeOf(fileBytes{}):          reflect.TypeOf([]byte{}),
+}
+
+// flagValue returns a copy of the value of the flag as the type its
+// constructor accepted, rather than the unexported type it may be stored as
+func flagValue(f *Flag) reflect.Value {
+	v := copyValue(reflect.ValueOf(f.AssignmentVar).Elem())
+	if t, ok := exportedTypes[v.Type()]; ok {
+		return v.Convert(t)
+	}
+	return v
+}
+
+// flagValueAs returns a copy of the value of the flag with the specified
+// name as a T.  An error is returned when the flag is not of type T.
+func flagValueAs[T any](f *Flag, name string) (T, error) {
+	var zero T
+	v := flagValue(f)
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if v.Type() != t {
+		return zero, errors.New("Flag " + name + " is of type " + v.Type().String() + ", not " + t.String())
+	}
+	return v.Interface().(T), nil
+}
+
+// Path returns the names of the subcommands used, from least to most
+// specific.  The parser itself is not included.
+func (r *ParseResult) Path() []string {
+	var path []string
+	for _, sc := range r.chain[1:] {
+		path = append(path, sc.Name)
+	}
+	return path
+}
+
+// Subcommand returns the most specific subcommand used, which is the
+// parser's own subcommand when no subcommands were used
+func (r *ParseResult) Subcommand() *Subcommand {
+	return r.chain[len(r.chain)-1]
+}
+
+// lookup returns the copy of the flag with the specified short or long name.
+// Flags are found the same way as Parser.Lookup.
+func (r *ParseResult) lookup(name string) *Flag {
+	_, f := resolveFlagInChain(r.chain, name)
+	if f == nil {
+		return nil
+	}
+	return r.flags[f]
+}
+
+// Value returns the value of the flag with the specified short or long name.
+// Flags that were not supplied have their default value.  The value has the
+// type accepted by the flag's constructor, such as uint64 for ByteSize.  False
+// is returned when no flag with the name can be used with the subcommands
+// used.
+func (r *ParseResult) Value(name string) (interface{}, bool) {
+	f := r.lookup(name)
+	if f == nil {
+		return nil, false
+	}
+	return flagValue(f).Interface(), true
+}
+
+// Source reports where the value of the flag with the specified short or
+// long name came from in this parse.  Values supplied to earlier parses are
+// defaults.
+func (r *ParseResult) Source(name string) ValueSource {
+	f := r.lookup(name)
+	if f == nil {
+		return SourceDefault
+	}
+	return f.source
+}
+
+// Changed determines if the flag with the specified short or long name was
+// supplied in the args
+func (r *ParseResult) Changed(name string) bool {
+	return r.Source(name) == SourceArgs
+}
+
+// RawValue returns the last value supplied for the flag with the specified
+// short or long name before it was parsed into the flag's type
+func (r *ParseResult) RawValue(name string) string {
+	f := r.lookup(name)
+	if f == nil {
+		return ""
+	}
+	return f.rawValue
+}
+
+// Positional returns the value supplied for the positional value with the
+// specified name.  False is returned when it was not supplied.
+func (r *ParseResult) Positional(name string) (string, bool) {
+	value, ok := r.positionals[name]
+	return value, ok
+}
+
+// Assign copies the value of the flag with the specified short or long name
+// into dest, which must be a pointer to a variable of the type accepted by
+// the flag's constructor, such as *string for File.  When no flag has the
+// name, the value of the positional value with the name is copied into dest,
+// which must then be a *string.  Positional values that were not supplied
+// have their default value.  Assign makes it possible to write the values of
+// each parse into variables owned by the caller.
+func (r *ParseResult) Assign(name string, dest interface{}) error {
+	var value reflect.Value
+	if f := r.lookup(name); f != nil {
+		value = flagValue(f)
+	} else if positional, ok := r.positionalValue(name); ok {
+		value = reflect.ValueOf(positional)
+	} else {
+		return errors.New("Flag or positional value " + name + " not found in subcommand " + r.Subcommand().Name)
+	}
+
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() || destValue.Elem().Type() != value.Type() {
+		return errors.New("Unable to assign " + name + " of type " + value.Type().String() + " to " + reflect.TypeOf(dest).String())
+	}
+	destValue.Elem().Set(value)
+	return nil
+}
+
+// positionalValue returns the value of the positional value with the
+// specified name, or its default when it was not supplied.  Positional values
+// of the parser and of the most specific subcommand used are searched.
+func (r *ParseResult) positionalValue(name string) (string, bool) {
+	if value, ok := r.positionals[name]; ok {
+		return value, true
+	}
+	for _, sc := range []*Subcommand{r.chain[0], r.Subcommand()} {
+		for _, pv := range sc.PositionalFlags {
+			if pv.Name == name {
+				return *pv.AssignmentVar, true
+			}
+		}
+	}
+	return "", false
+}
+
+// TrailingArguments returns everything supplied after a --
+func (r *ParseResult) TrailingArguments() []string {
+	return append([]string(nil), r.trailing...)
+}
+
+// Unknown returns every arg that was not used, exactly as it was supplied.
+// This includes flags that could not be resolved, along with their values,
+// and positional args that matched no subcommand or positional value.
+func (r *ParseResult) Unknown() []string {
+	return append([]string(nil), r.unknown...)
+}
+
+// HelpRequested determines if -h or --help was supplied while the parser
+// shows help with the h flag
+func (r *ParseResult) HelpRequested() bool {
+	return r.helpRequested
+}
+
+// VersionRequested determines if --version was supplied while the parser
+// shows the version with the version flag.  Args after the version flag are
+// not parsed.
+func (r *ParseResult) VersionRequested() bool {
+	return r.versionRequested
+}
+
+// ResultValue returns the value of the flag with the specified short or long
+// name from a ParseResult.  An error is returned when the flag is not found
+// or T is not the type accepted by the flag's constructor.
+func ResultValue[T any](r *ParseResult, name string) (T, error) {
+	f := r.lookup(name)
+	if f == nil {
+		var zero T
+		return zero, errors.New("Flag " + name + " not found in subcommand " + r.Subcommand().Name)
+	}
+	return flagValueAs[T](f, name)
+}
@@ -0,0 +1,282 @@
+package flaggy_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/integrii/flaggy"
+)
+
+// newResultParser creates a parser with a persistent flag, a slice flag with
+// defaults and a subcommand with its own flag and positional value
+func newResultParser() (*flaggy.Parser, *flaggy.Subcommand, *string, *[]string, *int, *string) {
+	p := flaggy.NewParser("app")
+	name := flaggy.AddP(&p.Subcommand, "n", "name", "default", "name flag")
+	tags := flaggy.AddP[[]string](&p.Subcommand, "t", "tag", []string{"a"}, "tag flag")
+	server := flaggy.NewSubcommand("server")
+	port := flaggy.AddP(server, "p", "port", 80, "port flag")
+	var file string
+	server.AddPositionalValue(&file, "file", 1, true, "file positional")
+	p.AttachSubcommand(server, 1)
+	return p, server, name, tags, port, &file
+}
+
+func TestParseArgsIntoTable(t *testing.T) {
+	p, server, name, tags, port, file := newResultParser()
+
+	cases := []struct {
+		args       []string
+		path       []string
+		name       string
+		tags       []string
+		port       interface{}
+		file       string
+		trailing   []string
+		unknown    []string
+		nameChange bool
+	}{
+		{args: nil, name: "default", tags: []string{"a"}},
+		{args: []string{"-n", "x", "-t", "b"}, name: "x", tags: []string{"a", "b"}, nameChange: true},
+		{args: []string{"server", "-p", "8080", "config.yaml"}, path: []string{"server"}, name: "default", tags: []string{"a"}, port: 8080, file: "config.yaml"},
+		{args: []string{"-t=c", "server", "f", "--", "-x"}, path: []string{"server"}, name: "default", tags: []string{"a", "c"}, port: 80, file: "f", trailing: []string{"-x"}},
+		{args: []string{"--bogus", "value", "extra"}, name: "default", tags: []string{"a"}, unknown: []string{"--bogus", "value", "extra"}},
+	}
+
+	// every case parses with the same definition
+	for _, c := range cases {
+		r, err := p.ParseArgsInto(c.args)
+		if err != nil {
+			t.Fatal(c.args, err)
+		}
+		if !reflect.DeepEqual(r.Path(), c.path) {
+			t.Fatal(c.args, "unexpected path:", r.Path())
+		}
+		if v, _ := flaggy.ResultValue[string](r, "name"); v != c.name {
+			t.Fatal(c.args, "unexpected name:", v)
+		}
+		if v, _ := r.Value("t"); !reflect.DeepEqual(v, c.tags) {
+			t.Fatal(c.args, "unexpected tags:", v)
+		}
+		if v, _ := r.Value("port"); v != c.port {
+			t.Fatal(c.args, "unexpected port:", v)
+		}
+		if v, _ := r.Positional("file"); v != c.file {
+			t.Fatal(c.args, "unexpected file:", v)
+		}
+		if !reflect.DeepEqual(r.TrailingArguments(), c.trailing) {
+			t.Fatal(c.args, "unexpected trailing arguments:", r.TrailingArguments())
+		}
+		if !reflect.DeepEqual(r.Unknown(), c.unknown) {
+			t.Fatal(c.args, "unexpected unknown args:", r.Unknown())
+		}
+		if r.Changed("name") != c.nameChange {
+			t.Fatal(c.args, "unexpected change of name")
+		}
+	}
+
+	// the definition was not changed by any of the parses
+	if *name != "default" || !reflect.DeepEqual(*tags, []string{"a"}) || *port != 80 || *file != "" {
+		t.Fatal("definition values changed:", *name, *tags, *port, *file)
+	}
+	if server.Used || p.Changed("name") || len(p.TrailingArguments) > 0 {
+		t.Fatal("definition state changed")
+	}
+
+	// the parser can still parse normally afterwards
+	err := p.ParseArgs([]string{"server", "-p", "1", "f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 1 || *file != "f" || !server.Used {
+		t.Fatal("ParseArgs did not assign values after ParseArgsInto")
+	}
+}
+
+func TestParseArgsIntoResultIsImmutable(t *testing.T) {
+	p, _, _, _, _, _ := newResultParser()
+	r, err := p.ParseArgsInto([]string{"-t", "b", "--", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, _ := flaggy.ResultValue[[]string](r, "tag")
+	tags[0] = "changed"
+	trailing := r.TrailingArguments()
+	trailing[0] = "changed"
+
+	if v, _ := r.Value("tag"); !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Fatal("result tags changed:", v)
+	}
+	if r.TrailingArguments()[0] != "x" {
+		t.Fatal("result trailing arguments changed")
+	}
+	if _, err := flaggy.ResultValue[int](r, "tag"); err == nil {
+		t.Fatal("expected an error reading a flag as the wrong type")
+	}
+	if _, ok := r.Value("port"); ok {
+		t.Fatal("found a flag of a subcommand that was not used")
+	}
+	var wrongType int
+	if err := r.Assign("tag", &wrongType); err == nil {
+		t.Fatal("expected an error assigning a flag to the wrong type")
+	}
+	if err := r.Assign("port", &wrongType); err == nil {
+		t.Fatal("expected an error assigning a flag of a subcommand that was not used")
+	}
+}
+
+func TestParseArgsIntoAfterParseArgs(t *testing.T) {
+	p, _, _, _, _, _ := newResultParser()
+	err := p.ParseArgs([]string{"-n", "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// values of the earlier parse are the defaults, but were not changed by
+	// this parse
+	r, err := p.ParseArgsInto([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := r.Value("name"); v != "v" {
+		t.Fatal("unexpected name:", v)
+	}
+	if r.Changed("name") || r.Source("name") != flaggy.SourceDefault || r.RawValue("name") != "" {
+		t.Fatal("result reported a change made by ParseArgs")
+	}
+
+	r, err = p.ParseArgsInto([]string{"-n", "w"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Changed("name") || r.Source("name") != flaggy.SourceArgs || r.RawValue("name") != "w" {
+		t.Fatal("result did not report the change made by ParseArgsInto")
+	}
+	if p.Lookup("name").Source() != flaggy.SourceArgs || p.Lookup("name").RawValue() != "v" {
+		t.Fatal("ParseArgsInto changed the source of the parser's flag")
+	}
+}
+
+func TestParseArgsIntoErrors(t *testing.T) {
+	p, _, _, _, _, _ := newResultParser()
+
+	// a missing required positional value
+	if _, err := p.ParseArgsInto([]string{"server"}); err == nil {
+		t.Fatal("expected an error for a missing required positional value")
+	}
+
+	// a flag missing its value
+	if _, err := p.ParseArgsInto([]string{"--name"}); err == nil {
+		t.Fatal("expected an error for a flag without a value")
+	}
+
+	// a value that can not be parsed
+	if _, err := p.ParseArgsInto([]string{"server", "-p", "x", "f"}); err == nil {
+		t.Fatal("expected an error for an invalid value")
+	}
+
+	// a flag conflicting with the built-in help flag is returned as an error
+	// instead of exiting
+	conflicting := flaggy.NewParser("conflicting")
+	conflicting.String(new(string), "", "help", "conflicting help flag")
+	if _, err := conflicting.ParseArgsInto(nil); err == nil {
+		t.Fatal("expected an error for a flag conflicting with the help flag")
+	}
+
+	// help is reported instead of displayed, and skips required positionals
+	r, err := p.ParseArgsInto([]string{"server", "--help"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.HelpRequested() || r.Subcommand().Name != "server" {
+		t.Fatal("help request not reported for server")
+	}
+}
+
+func TestParseArgsIntoStoredTypes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "contents")
+	err := os.WriteFile(file, []byte("data"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// these flags store their values as unexported types, but results use the
+	// types their constructors accept
+	p := flaggy.NewParser("app")
+	p.ByteSize(new(uint64), "", "size", "size flag")
+	p.ByteSizeSlice(new([]uint64), "", "sizes", "sizes flag")
+	p.Percentage(new(float64), "", "percent", "percent flag")
+	p.PercentageSlice(new([]float64), "", "percents", "percents flag")
+	p.File(new(string), "", "file", "file flag")
+	p.ExistingFile(new(string), "", "existing-file", "existing file flag")
+	p.ExistingDir(new(string), "", "existing-dir", "existing dir flag")
+	p.OutputFile(new(string), "", "output-file", "output file flag")
+	p.HexBytes(new([]byte), "", "hex", "hex flag")
+	p.Base64Bytes(new([]byte), "", "base64", "base64 flag")
+	p.BytesFromFile(new([]byte), "", "bytes-from-file", "bytes from file flag")
+
+	r, err := p.ParseArgsInto([]string{
+		"--size", "1KiB", "--sizes", "1,2KB", "--percent", "50%", "--percents", "10,20",
+		"--file", "f", "--existing-file", file, "--existing-dir", dir, "--output-file", file,
+		"--hex", "6869", "--base64", "aGk=", "--bytes-from-file", file,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"size":            uint64(1024),
+		"sizes":           []uint64{1, 2000},
+		"percent":         0.5,
+		"percents":        []float64{0.1, 0.2},
+		"file":            "f",
+		"existing-file":   file,
+		"existing-dir":    dir,
+		"output-file":     file,
+		"hex":             []byte("hi"),
+		"base64":          []byte("hi"),
+		"bytes-from-file": []byte("data"),
+	}
+	for name, value := range expected {
+		if v, ok := r.Value(name); !ok || !reflect.DeepEqual(v, value) {
+			t.Fatal("unexpected value of", name, v)
+		}
+		dest := reflect.New(reflect.TypeOf(value))
+		err = r.Assign(name, dest.Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dest.Elem().Interface(), value) {
+			t.Fatal("unexpected value assigned for", name, dest.Elem().Interface())
+		}
+	}
+
+	if v, err := flaggy.ResultValue[uint64](r, "size"); err != nil || v != 1024 {
+		t.Fatal("unexpected size:", v, err)
+	}
+	if v, err := flaggy.ResultValue[[]uint64](r, "sizes"); err != nil || !reflect.DeepEqual(v, []uint64{1, 2000}) {
+		t.Fatal("unexpected sizes:", v, err)
+	}
+	if v, err := flaggy.ResultValue[float64](r, "percent"); err != nil || v != 0.5 {
+		t.Fatal("unexpected percent:", v, err)
+	}
+	if v, err := flaggy.ResultValue[[]float64](r, "percents"); err != nil || !reflect.DeepEqual(v, []float64{0.1, 0.2}) {
+		t.Fatal("unexpected percents:", v, err)
+	}
+	for _, name := range []string{"file", "existing-file", "existing-dir", "output-file"} {
+		if v, err := flaggy.ResultValue[string](r, name); err != nil || v != expected[name] {
+			t.Fatal("unexpected value of", name, v, err)
+		}
+	}
+	for _, name := range []string{"hex", "base64", "bytes-from-file"} {
+		if v, err := flaggy.ResultValue[[]byte](r, name); err != nil || !reflect.DeepEqual(v, expected[name]) {
+			t.Fatal("unexpected value of", name, v, err)
+		}
+	}
+	if _, err := flaggy.ResultValue[[]byte](r, "file"); err == nil {
+		t.Fatal("expected an error reading a file flag as bytes")
+	}
+}
@@ -0,0 +1,126 @@
+package flaggy
+
+import (
+	"log"
+)
+
+// FlagScope determines which subcommands a flag can be used with
+type FlagScope int
+
+// The available flag scopes
+const (
+	ScopeDefault    FlagScope = iota // the flag can be used with its subcommand and all of its descendants, and flags of the parser take precedence over flags of subcommands with the same name
+	ScopePersistent                  // the flag can be used with its subcommand and all of its descendants, which can not reuse its name
+	ScopeLocal                       // the flag can only be used with its own subcommand
+)
+
+// SetFlagScope sets the scope of the flags with the specified short or long
+// names.  Making a flag persistent panics if a subcommand beneath it reuses
+// its name, as does attaching or adding such a flag afterwards.
+func (sc *Subcommand) SetFlagScope(scope FlagScope, flagNames ...string) {
+	for _, name := range flagNames {
+		f := sc.lookupFlag(name)
+		if f == nil {
+			log.Panicln("Unable to set scope on flag " + name + " because it does not exist on subcommand " + sc.Name)
+		}
+		f.Scope = scope
+	}
+	sc.ensureNoShadowedFlags()
+}
+
+// ensureNoShadowedFlags panics if any flag in the tree of subcommands that
+// this subcommand belongs to has the same name as a flag of one of its
+// parents that was made persistent.  Such a flag would make the parent flag
+// impossible to use.  Flags with the default scope may share names.
+func (sc *Subcommand) ensureNoShadowedFlags() {
+	root := sc
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.ensureNoShadowedFlagsBeneath(nil)
+}
+
+// inheritedFlag is a persistent flag along with the subcommand that owns it
+type inheritedFlag struct {
+	sc   *Subcommand
+	flag *Flag
+}
+
+// ensureNoShadowedFlagsBeneath checks the flags of this subcommand and its
+// descendants against the supplied persistent flags of its parents
+func (sc *Subcommand) ensureNoShadowedFlagsBeneath(inherited []inheritedFlag) {
+	for _, f := range sc.Flags {
+		for _, parent := range inherited {
+			var name string
+			if f.LongName != "" && f.LongName == parent.flag.LongName {
+				name = f.LongName
+			}
+			if f.ShortName != "" && f.ShortName == parent.flag.ShortName {
+				name = f.ShortName
+			}
+			if name != "" {
+				log.Panicln("Flag " + name + " on subcommand " + sc.Name + " shadows the persistent flag " + name + " on subcommand " + parent.sc.Name + ". Make one of the flags local or rename it.")
+			}
+		}
+	}
+
+	// copy the inherited flags so that siblings do not share additions
+	nextInherited := append([]inheritedFlag{}, inherited...)
+	for _, f := range sc.Flags {
+		if f.Scope == ScopePersistent {
+			nextInherited = append(nextInherited, inheritedFlag{sc: sc, flag: f})
+		}
+	}
+	for _, child := range sc.Subcommands {
+		child.ensureNoShadowedFlagsBeneath(nextInherited)
+	}
+}
+
+// resolveFlag finds the flag with the specified short or long name for the
+// most specific subcommand used.  See resolveFlagInChain.
+func (p *Parser) resolveFlag(name string) (*Subcommand, *Flag) {
+	return resolveFlagInChain(p.activeSubcommands(), name)
+}
+
+// resolveFlagInChain finds the flag with the specified short or long name for
+// the last subcommand in the chain.  A flag of the parser with the default
+// scope is used first, as flags of the parser have always taken precedence
+// over flags of subcommands with the same name.  Otherwise, that
+// subcommand's own flags are searched first, followed by the flags of each of
+// its parents that are not local.
+func resolveFlagInChain(chain []*Subcommand, name string) (*Subcommand, *Flag) {
+	if len(chain) > 1 {
+		if f := chain[0].lookupFlag(name); f != nil && f.Scope == ScopeDefault {
+			return chain[0], f
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		f := chain[i].lookupFlag(name)
+		if f == nil {
+			continue
+		}
+		if f.Scope == ScopeLocal && i != len(chain)-1 {
+			continue
+		}
+		return chain[i], f
+	}
+	return nil, nil
+}
+
+// inheritedFlags returns the flags that are not local of every parent of the
+// most specific subcommand used, starting with its closest parent
+func (p *Parser) inheritedFlags() []*Flag {
+	var flags []*Flag
+	chain := p.activeSubcommands()
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] == p.subcommandContext {
+			continue
+		}
+		for _, f := range chain[i].Flags {
+			if f.Scope != ScopeLocal {
+				flags = append(flags, f)
+			}
+		}
+	}
+	return flags
+}
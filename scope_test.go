@@ -0,0 +1,206 @@
+package flaggy
+
+import (
+	"testing"
+)
+
+// newScopeParser creates a parser with flags on every level of a chain of
+// subcommands: app server start
+func newScopeParser() (*Parser, *Subcommand, *Subcommand) {
+	p := NewParser("app")
+	server := NewSubcommand("server")
+	start := NewSubcommand("start")
+	p.AttachSubcommand(server, 1)
+	server.AttachSubcommand(start, 1)
+	return p, server, start
+}
+
+func TestPersistentFlagsResolveAgainstChain(t *testing.T) {
+	p, server, start := newScopeParser()
+	tags := AddP[[]string](&p.Subcommand, "t", "tag", nil, "tag flag")
+	hosts := AddP[[]string](server, "H", "host", nil, "host flag")
+	detach := AddP(start, "d", "detach", false, "detach flag")
+
+	err := p.ParseArgs([]string{"-t", "a", "server", "start", "--host", "x", "-d", "-t", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*tags) != 2 || (*tags)[1] != "b" {
+		t.Fatal("root persistent flag incorrect", *tags)
+	}
+	if len(*hosts) != 1 || (*hosts)[0] != "x" {
+		t.Fatal("intermediate persistent flag incorrect", *hosts)
+	}
+	if !*detach {
+		t.Fatal("start flag was not set")
+	}
+}
+
+func TestLocalFlags(t *testing.T) {
+	p, server, _ := newScopeParser()
+	rootVerbose := AddP(&p.Subcommand, "v", "verbose", false, "root verbose flag")
+	p.SetFlagScope(ScopeLocal, "v")
+	serverVerbose := AddP(server, "v", "verbose", 0, "server verbose level")
+
+	err := p.ParseArgs([]string{"server", "-v", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *serverVerbose != 3 || *rootVerbose {
+		t.Fatal("local flag resolved incorrectly", *serverVerbose, *rootVerbose)
+	}
+
+	// a local flag used without a subcommand
+	p, _, _ = newScopeParser()
+	rootVerbose = AddP(&p.Subcommand, "v", "verbose", false, "root verbose flag")
+	p.SetFlagScope(ScopeLocal, "v")
+	err = p.ParseArgs([]string{"-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*rootVerbose || p.Lookup("v") == nil {
+		t.Fatal("local flag not set on its own subcommand")
+	}
+
+	// a local flag used with a child subcommand
+	p, _, _ = newScopeParser()
+	AddP(&p.Subcommand, "v", "verbose", false, "root verbose flag")
+	p.SetFlagScope(ScopeLocal, "v")
+	err = p.ParseArgs([]string{"-v", "server"})
+	if err == nil {
+		t.Fatal("expected an error using a local flag with a child subcommand")
+	}
+	if p.Lookup("v") != nil {
+		t.Fatal("lookup found a local flag of a parent subcommand")
+	}
+}
+
+func TestShadowedFlagsPanic(t *testing.T) {
+	expectPanic := func(name string, fn func()) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected a panic when", name)
+			}
+		}()
+		fn()
+	}
+
+	expectPanic("attaching a subcommand that shadows a flag", func() {
+		p := NewParser("app")
+		AddP(&p.Subcommand, "o", "output", "", "output flag")
+		p.SetFlagScope(ScopePersistent, "output")
+		server := NewSubcommand("server")
+		AddP(server, "o", "out", "", "out flag")
+		p.AttachSubcommand(server, 1)
+	})
+	expectPanic("adding a flag that shadows a flag", func() {
+		_, server, start := newScopeParser()
+		AddP(server, "", "output", "", "output flag")
+		server.SetFlagScope(ScopePersistent, "output")
+		AddP(start, "", "output", "", "output flag")
+	})
+	expectPanic("adding a flag that is shadowed", func() {
+		_, server, start := newScopeParser()
+		AddP(start, "", "output", "", "output flag")
+		AddP(server, "", "output", "", "output flag")
+		server.SetFlagScope(ScopePersistent, "output")
+	})
+	expectPanic("making a shadowed flag persistent", func() {
+		p, server, _ := newScopeParser()
+		AddP(&p.Subcommand, "", "output", "", "output flag")
+		p.SetFlagScope(ScopeLocal, "output")
+		AddP(server, "", "output", "", "output flag")
+		p.SetFlagScope(ScopePersistent, "output")
+	})
+}
+
+func TestDefaultScopeAllowsSharedNames(t *testing.T) {
+	p := NewParser("app")
+	rootOutput := AddP(&p.Subcommand, "o", "output", "", "root output flag")
+	server := NewSubcommand("server")
+	serverOutput := AddP(server, "o", "output", "", "server output flag")
+	start := NewSubcommand("start")
+	serverPort := AddP(server, "p", "port", 0, "server port flag")
+	startPort := AddP(start, "p", "port", 0, "start port flag")
+	server.AttachSubcommand(start, 1)
+	p.AttachSubcommand(server, 1)
+
+	err := p.ParseArgs([]string{"server", "start", "-o", "x", "--port", "8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rootOutput != "x" || *serverOutput != "" {
+		t.Fatal("flag of the parser did not take precedence", *rootOutput, *serverOutput)
+	}
+	if *startPort != 8080 || *serverPort != 0 {
+		t.Fatal("flag of the nearest subcommand was not used", *startPort, *serverPort)
+	}
+}
+
+func TestHelpListsInheritedFlags(t *testing.T) {
+	p, server, start := newScopeParser()
+	AddP(&p.Subcommand, "c", "config", "", "config flag")
+	AddP(&p.Subcommand, "", "version-check", false, "local root flag")
+	p.SetFlagScope(ScopeLocal, "version-check")
+	AddP(server, "p", "port", 80, "port flag")
+	AddP(start, "d", "detach", false, "detach flag")
+	err := p.ParseArgs([]string{"server", "start"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := Help{}
+	h.ExtractValues(p, "")
+	names := map[string]bool{}
+	for _, f := range h.Flags {
+		names[f.LongName] = true
+	}
+	if !names["detach"] || !names["port"] || !names["config"] {
+		t.Fatal("help is missing inherited flags", names)
+	}
+	if names["version-check"] {
+		t.Fatal("help lists a local flag of a parent subcommand")
+	}
+}
+
+func TestStrictFlagPositions(t *testing.T) {
+	p, server, start := newScopeParser()
+	p.StrictFlagPositions = true
+	tags := AddP[[]string](&p.Subcommand, "t", "tag", nil, "tag flag")
+	port := AddP(server, "p", "port", 80, "port flag")
+	detach := AddP(start, "d", "detach", false, "detach flag")
+	err := p.ParseArgs([]string{"-t", "a", "server", "-p", "8080", "-t", "b", "start", "-d", "-p", "9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*tags) != 2 || *port != 9090 || !*detach {
+		t.Fatal("strict flags parsed incorrectly", *tags, *port, *detach)
+	}
+
+	// flags placed before the subcommand that defines them
+	for _, args := range [][]string{
+		{"--port", "8080", "server"},
+		{"server", "-d", "start"},
+		{"-p=8080", "server", "start"},
+	} {
+		p, server, start := newScopeParser()
+		p.StrictFlagPositions = true
+		AddP(server, "p", "port", 80, "port flag")
+		AddP(start, "d", "detach", false, "detach flag")
+		err := p.ParseArgs(args)
+		if err == nil {
+			t.Fatal("expected an error for a flag before its subcommand", args)
+		}
+	}
+
+	// without strict mode, flags can be placed anywhere
+	p, server, _ = newScopeParser()
+	port = AddP(server, "p", "port", 80, "port flag")
+	err = p.ParseArgs([]string{"--port", "8080", "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 8080 {
+		t.Fatal("port flag incorrect", *port)
+	}
+}
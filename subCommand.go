@@ -1,12 +1,14 @@
 package flaggy
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -28,6 +30,18 @@ type Subcommand struct {
 	AdditionalHelpAppend  string        // additional appended message when Help is displayed
 	Used                  bool          // indicates this subcommand was found and parsed
 	Hidden                bool          // indicates this subcommand should be hidden from help
+	Group                 string        // the help section this subcommand is listed under in its parent's help
+	Examples              []Example     // example invocations displayed in help
+	flagGroupOrder        []string      // the order flag groups are displayed in help
+	subcommandGroupOrder  []string      // the order subcommand groups are displayed in help
+	parent                *Subcommand   // the subcommand this one is attached to
+}
+
+// Example represents an example invocation of a subcommand that is displayed
+// in its help output
+type Example struct {
+	Command     string // the example command line, such as "app serve --port 80"
+	Description string // an optional explanation of what the example does
 }
 
 // NewSubcommand creates a new subcommand that can have flags or PositionalFlags
@@ -43,324 +57,6 @@ func NewSubcommand(name string) *Subcommand {
 	return newSC
 }
 
-// parseAllFlagsFromArgs parses the non-positional flags such as -f or -v=value
-// out of the supplied args and returns the resulting positional items in order,
-// all the flag names found (without values), a bool to indicate if help was
-// requested, and any errors found during parsing
-func (sc *Subcommand) parseAllFlagsFromArgs(p *Parser, args []string) ([]string, bool, error) {
-
-	var positionalOnlyArguments []string
-	var helpRequested bool // indicates the user has supplied -h and we
-	// should render help if we are the last subcommand
-
-	// indicates we should skip the next argument, like when parsing a flag
-	// that separates key and value by space
-	var skipNext bool
-
-	// endArgfound indicates that a -- was found and everything
-	// remaining should be added to the trailing arguments slices
-	var endArgFound bool
-
-	// find all the normal flags (not positional) and parse them out
-	for i, a := range args {
-
-		debugPrint("parsing arg:", a)
-
-		// evaluate if there is a following arg to avoid panics
-		var nextArgExists bool
-		var nextArg string
-		if len(args)-1 >= i+1 {
-			nextArgExists = true
-			nextArg = args[i+1]
-		}
-
-		// if end arg -- has been found, just add everything to TrailingArguments
-		if endArgFound {
-			if !p.trailingArgumentsExtracted {
-				p.TrailingArguments = append(p.TrailingArguments, a)
-			}
-			continue
-		}
-
-		// skip this run if specified
-		if skipNext {
-			skipNext = false
-			debugPrint("skipping flag because it is an arg:", a)
-			continue
-		}
-
-		// parse the flag into its name for consideration without dashes
-		flagName := parseFlagToName(a)
-
-		// if the flag being passed is version or v and the option to display
-		// version with version flags, then display version
-		if p.ShowVersionWithVersionFlag {
-			if flagName == versionFlagLongName {
-				p.ShowVersionAndExit()
-			}
-		}
-
-		// if the show Help on h flag option is set, then show Help when h or Help
-		// is passed as an option
-		if p.ShowHelpWithHFlag {
-			if flagName == helpFlagShortName || flagName == helpFlagLongName {
-				// Ensure this is the last subcommand passed so we give the correct
-				// help output
-				helpRequested = true
-				continue
-			}
-		}
-
-		// determine what kind of flag this is
-		argType := determineArgType(a)
-
-		// strip flags from arg
-		// debugPrint("Parsing flag named", a, "of type", argType)
-
-		// depending on the flag type, parse the key and value out, then apply it
-		switch argType {
-		case argIsFinal:
-			// debugPrint("Arg", i, "is final:", a)
-			endArgFound = true
-		case argIsPositional:
-			// debugPrint("Arg is positional or subcommand:", a)
-			// this positional argument into a slice of their own, so that
-			// we can determine if its a subcommand or positional value later
-			positionalOnlyArguments = append(positionalOnlyArguments, a)
-			// track this as a parsed value with the subcommand
-			sc.addParsedPositionalValue(a)
-		case argIsFlagWithSpace: // a flag with a space. ex) -k v or --key value
-			a = parseFlagToName(a)
-
-			// debugPrint("Arg", i, "is flag with space:", a)
-			// parse next arg as value to this flag and apply to subcommand flags
-			// if the flag is a bool flag, then we check for a following positional
-			// and skip it if necessary
-			if flagIsBool(sc, p, a) {
-				debugPrint(sc.Name, "bool flag", a, "next var is:", nextArg)
-				// set the value in this subcommand and its root parser
-				valueSet, err := setValueForParsers(a, "true", p, sc)
-
-				// if an error occurs, just return it and quit parsing
-				if err != nil {
-					return []string{}, false, err
-				}
-
-				// log all values parsed by this subcommand.  We leave the value blank
-				// because the bool value had no explicit true or false supplied
-				if valueSet {
-					sc.addParsedFlag(a, "")
-				}
-
-				// we've found and set a standalone bool flag, so we move on to the next
-				// argument in the list of arguments
-				continue
-			}
-
-			skipNext = true
-			// debugPrint(sc.Name, "NOT bool flag", a)
-
-			// if the next arg was not found, then show a Help message
-			if !nextArgExists {
-				p.ShowHelpWithMessage("Expected a following arg for flag " + a + ", but it did not exist.")
-				exitOrPanic(2)
-			}
-			valueSet, err := setValueForParsers(a, nextArg, p, sc)
-			if err != nil {
-				return []string{}, false, err
-			}
-
-			// log all parsed values in the subcommand
-			if valueSet {
-				sc.addParsedFlag(a, nextArg)
-			}
-		case argIsFlagWithValue: // a flag with an equals sign. ex) -k=v or --key=value
-			// debugPrint("Arg", i, "is flag with value:", a)
-			a = parseFlagToName(a)
-
-			// parse flag into key and value and apply to subcommand flags
-			key, val := parseArgWithValue(a)
-
-			// set the value in this subcommand and its root parser
-			valueSet, err := setValueForParsers(key, val, p, sc)
-			if err != nil {
-				return []string{}, false, err
-			}
-
-			// log all values parsed by the subcommand
-			if valueSet {
-				sc.addParsedFlag(a, val)
-			}
-		}
-	}
-
-	return positionalOnlyArguments, helpRequested, nil
-}
-
-// findAllParsedValues finds all values parsed by all subcommands and this
-// subcommand and its child subcommands
-func (sc *Subcommand) findAllParsedValues() []parsedValue {
-	parsedValues := sc.ParsedValues
-	for _, sc := range sc.Subcommands {
-		// skip unused subcommands
-		if !sc.Used {
-			continue
-		}
-		parsedValues = append(parsedValues, sc.findAllParsedValues()...)
-	}
-	return parsedValues
-}
-
-// parse causes the argument parser to parse based on the supplied []string.
-// depth specifies the non-flag subcommand positional depth.  A slice of flags
-// and subcommands parsed is returned so that the parser can ultimately decide
-// if there were any unexpected values supplied by the user
-func (sc *Subcommand) parse(p *Parser, args []string, depth int) error {
-
-	debugPrint("- Parsing subcommand", sc.Name, "with depth of", depth, "and args", args)
-
-	// if a command is parsed, its used
-	sc.Used = true
-	debugPrint("used subcommand", sc.Name, sc.ShortName)
-	if len(sc.Name) > 0 {
-		sc.addParsedPositionalValue(sc.Name)
-	}
-	if len(sc.ShortName) > 0 {
-		sc.addParsedPositionalValue(sc.ShortName)
-	}
-
-	// as subcommands are used, they become the context of the parser.  This helps
-	// us understand how to display help based on which subcommand is being used
-	p.subcommandContext = sc
-
-	// ensure that help and version flags are not used if the parser has the
-	// built-in help and version flags enabled
-	if p.ShowHelpWithHFlag {
-		sc.ensureNoConflictWithBuiltinHelp()
-	}
-	if p.ShowVersionWithVersionFlag {
-		sc.ensureNoConflictWithBuiltinVersion()
-	}
-
-	// Parse the normal flags out of the argument list and return the positionals
-	// (subcommands and positional values), along with the flags used.
-	// Then the flag values are applied to the parent parser and the current
-	// subcommand being parsed.
-	positionalOnlyArguments, helpRequested, err := sc.parseAllFlagsFromArgs(p, args)
-	if err != nil {
-		return err
-	}
-
-	// indicate that trailing arguments have been extracted, so that they aren't
-	// appended a second time
-	p.trailingArgumentsExtracted = true
-
-	// loop over positional values and look for their matching positional
-	// parameter, or their positional command.  If neither are found, then
-	// we throw an error
-	var parsedArgCount int
-	for pos, v := range positionalOnlyArguments {
-
-		// the first relative positional argument will be human natural at position 1
-		// but offset for the depth of relative commands being parsed for currently.
-		relativeDepth := pos - depth + 1
-		// debugPrint("Parsing positional only position", relativeDepth, "with value", v)
-
-		if relativeDepth < 1 {
-			// debugPrint(sc.Name, "skipped value:", v)
-			continue
-		}
-		parsedArgCount++
-
-		// determine subcommands and parse them by positional value and name
-		for _, cmd := range sc.Subcommands {
-			// debugPrint("Subcommand being compared", relativeDepth, "==", cmd.Position, "and", v, "==", cmd.Name, "==", cmd.ShortName)
-			if relativeDepth == cmd.Position && (v == cmd.Name || v == cmd.ShortName) {
-				debugPrint("Decending into positional subcommand", cmd.Name, "at relativeDepth", relativeDepth, "and absolute depth", depth+1)
-				return cmd.parse(p, args, depth+parsedArgCount) // continue recursive positional parsing
-			}
-		}
-
-		// determine positional args and parse them by positional value and name
-		var foundPositional bool
-		for _, val := range sc.PositionalFlags {
-			if relativeDepth == val.Position {
-				debugPrint("Found a positional value at relativePos:", relativeDepth, "value:", v)
-
-				// set original value for help output
-				val.defaultValue = *val.AssignmentVar
-
-				// defrerence the struct pointer, then set the pointer property within it
-				*val.AssignmentVar = v
-				// debugPrint("set positional to value", *val.AssignmentVar)
-				foundPositional = true
-				val.Found = true
-				break
-			}
-		}
-
-		// if there aren't any positional flags but there are subcommands that
-		// were not used, display a useful message with subcommand options.
-		if !foundPositional && p.ShowHelpOnUnexpected {
-			debugPrint("No positional at position", relativeDepth)
-			var foundSubcommandAtDepth bool
-			for _, cmd := range sc.Subcommands {
-				if cmd.Position == relativeDepth {
-					foundSubcommandAtDepth = true
-				}
-			}
-
-			// if there is a subcommand here but it was not specified, display them all
-			// as a suggestion to the user before exiting.
-			if foundSubcommandAtDepth {
-				// determine which name to use in upcoming help output
-				fmt.Fprintln(os.Stderr, sc.Name+":", "No subcommand or positional value found at position", strconv.Itoa(relativeDepth)+".")
-				var output string
-				for _, cmd := range sc.Subcommands {
-					if cmd.Hidden {
-						continue
-					}
-					output = output + " " + cmd.Name
-				}
-				// if there are available subcommands, let the user know
-				if len(output) > 0 {
-					output = strings.TrimLeft(output, " ")
-					fmt.Println("Available subcommands:", output)
-				}
-				exitOrPanic(2)
-			}
-
-			// if there were not any flags or subcommands at this position at all, then
-			// throw an error (display Help if necessary)
-			p.ShowHelpWithMessage("Unexpected argument: " + v)
-			exitOrPanic(2)
-		}
-	}
-
-	// if help was requested and we should show help when h is passed,
-	if helpRequested && p.ShowHelpWithHFlag {
-		p.ShowHelp()
-		exitOrPanic(0)
-	}
-
-	// find any positionals that were not used on subcommands that were
-	// found and throw help (unknown argument) in the global parse or subcommand
-	for _, pv := range p.PositionalFlags {
-		if pv.Required && !pv.Found {
-			p.ShowHelpWithMessage("Required global positional variable " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
-			exitOrPanic(2)
-		}
-	}
-	for _, pv := range sc.PositionalFlags {
-		if pv.Required && !pv.Found {
-			p.ShowHelpWithMessage("Required positional of subcommand " + sc.Name + " named " + pv.Name + " not found at position " + strconv.Itoa(pv.Position))
-			exitOrPanic(2)
-		}
-	}
-
-	return nil
-}
-
 // addParsedFlag makes it easy to append flag values parsed by the subcommand
 func (sc *Subcommand) addParsedFlag(key string, value string) {
 	sc.ParsedValues = append(sc.ParsedValues, newParsedValue(key, value, false))
@@ -375,14 +71,59 @@ func (sc *Subcommand) addParsedPositionalValue(value string) {
 // FlagExists lets you know if the flag name exists as either a short or long
 // name in the (sub)command
 func (sc *Subcommand) FlagExists(name string) bool {
+	return sc.lookupFlag(name) != nil
+}
 
+// Changed determines if the flag with the specified short or long name on
+// this subcommand was set while parsing
+func (sc *Subcommand) Changed(name string) bool {
+	f := sc.lookupFlag(name)
+	return f != nil && f.Changed()
+}
+
+// lookupFlag returns the flag with the specified short or long name on this
+// subcommand, or nil if there is none
+func (sc *Subcommand) lookupFlag(name string) *Flag {
 	for _, f := range sc.Flags {
 		if f.HasName(name) {
-			return true
+			return f
 		}
 	}
+	return nil
+}
+
+// SetFlagGroup places the flags with the specified short or long names into
+// the named group.  Flags in the same group are listed together under their
+// own heading in help output.
+func (sc *Subcommand) SetFlagGroup(group string, flagNames ...string) {
+	for _, name := range flagNames {
+		var found bool
+		for _, f := range sc.Flags {
+			if f.HasName(name) {
+				f.Group = group
+				found = true
+			}
+		}
+		if !found {
+			log.Panicln("Unable to set group " + group + " on flag " + name + " because it does not exist on subcommand " + sc.Name)
+		}
+	}
+}
+
+// SetFlagGroupOrder sets the order that flag groups are displayed in help.
+// Groups not specified here are displayed after these, in the order that
+// their first flag was added.  Flags without a group are always displayed
+// first.
+func (sc *Subcommand) SetFlagGroupOrder(groups ...string) {
+	sc.flagGroupOrder = groups
+}
 
-	return false
+// SetSubcommandGroupOrder sets the order that groups of child subcommands are
+// displayed in help.  Groups not specified here are displayed after these, in
+// the order that their first subcommand was attached.  Subcommands without a
+// group are always displayed first.
+func (sc *Subcommand) SetSubcommandGroupOrder(groups ...string) {
+	sc.subcommandGroupOrder = groups
 }
 
 // AttachSubcommand adds a possible subcommand to the Parser.
@@ -414,13 +155,18 @@ func (sc *Subcommand) AttachSubcommand(newSC *Subcommand, relativePosition int)
 		}
 	}
 
+	newSC.parent = sc
 	sc.Subcommands = append(sc.Subcommands, newSC)
+
+	// ensure the new subcommand's flags do not shadow persistent flags
+	sc.ensureNoShadowedFlags()
 }
 
 // add is a "generic" to add flags of any type. Checks the supplied parent
 // parser to ensure that the user isn't setting version or help flags that
-// conflict with the built-in help and version flag behavior.
-func (sc *Subcommand) add(assignmentVar interface{}, shortName string, longName string, description string) {
+// conflict with the built-in help and version flag behavior.  The new flag is
+// returned so that type specific options can be set on it.
+func (sc *Subcommand) add(assignmentVar interface{}, shortName string, longName string, description string) *Flag {
 
 	// if the flag is already used, throw an error
 	for _, existingFlag := range sc.Flags {
@@ -439,6 +185,12 @@ func (sc *Subcommand) add(assignmentVar interface{}, shortName string, longName
 		Description:   description,
 	}
 	sc.Flags = append(sc.Flags, &newFlag)
+
+	// ensure the flag does not shadow, or get shadowed by, a persistent flag
+	if sc.parent != nil || len(sc.Subcommands) > 0 {
+		sc.ensureNoShadowedFlags()
+	}
+	return &newFlag
 }
 
 // String adds a new string flag
@@ -464,11 +216,118 @@ func (sc *Subcommand) BoolSlice(assignmentVar *[]bool, shortName string, longNam
 }
 
 // ByteSlice adds a new slice of bytes flag
-// Specify the flag multiple times to fill the slice.  Takes hex as input.
+// Specify the flag multiple times to fill the slice.  Each value is a single
+// decimal byte.  See HexBytes and Base64Bytes for encoded input.
 func (sc *Subcommand) ByteSlice(assignmentVar *[]byte, shortName string, longName string, description string) {
 	sc.add(assignmentVar, shortName, longName, description)
 }
 
+// Time adds a new time.Time flag.  Values are parsed with the supplied layout,
+// which defaults to time.RFC3339 when blank.  The value "now" and values
+// relative to now such as -2h or +30m are also accepted.
+func (sc *Subcommand) Time(assignmentVar *time.Time, layout string, shortName string, longName string, description string) {
+	f := sc.add(assignmentVar, shortName, longName, description)
+	f.timeLayout = layout
+}
+
+// TimeSlice adds a new time.Time slice flag.  Values are parsed the same way
+// as Time flags.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) TimeSlice(assignmentVar *[]time.Time, layout string, shortName string, longName string, description string) {
+	f := sc.add(assignmentVar, shortName, longName, description)
+	f.timeLayout = layout
+}
+
+// ByteSize adds a new byte size flag that parses sizes with units into a
+// number of bytes.  KB, MB and friends are powers of 1000 while KiB, MiB and
+// friends are powers of 1024.
+// Example values: 512, 10MiB, 1.5GB
+func (sc *Subcommand) ByteSize(assignmentVar *uint64, shortName string, longName string, description string) {
+	sc.add((*byteSize)(assignmentVar), shortName, longName, description)
+}
+
+// ByteSizeSlice adds a new byte size slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) ByteSizeSlice(assignmentVar *[]uint64, shortName string, longName string, description string) {
+	sc.add((*byteSizeSlice)(assignmentVar), shortName, longName, description)
+}
+
+// Percentage adds a new percentage flag.  The value is stored as a fraction,
+// so 50% is stored as 0.5.  The % sign is optional.
+// Example values: 50%, 12.5
+func (sc *Subcommand) Percentage(assignmentVar *float64, shortName string, longName string, description string) {
+	sc.add((*percentage)(assignmentVar), shortName, longName, description)
+}
+
+// PercentageSlice adds a new percentage slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) PercentageSlice(assignmentVar *[]float64, shortName string, longName string, description string) {
+	sc.add((*percentageSlice)(assignmentVar), shortName, longName, description)
+}
+
+// StringMap adds a new map of strings flag.  Values are key=value pairs,
+// either comma separated or supplied by specifying the flag multiple times.
+// Example values: env=prod, team=infra,tier=web
+func (sc *Subcommand) StringMap(assignmentVar *map[string]string, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// IntMap adds a new map of ints flag.  Values are key=value pairs, either
+// comma separated or supplied by specifying the flag multiple times.
+func (sc *Subcommand) IntMap(assignmentVar *map[string]int, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// DurationMap adds a new map of time.Duration flag.  Values are key=value
+// pairs, either comma separated or supplied by specifying the flag multiple
+// times.  Durations are parsed with time.ParseDuration().
+func (sc *Subcommand) DurationMap(assignmentVar *map[string]time.Duration, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// File adds a new file path flag.  Environment variables and a leading ~ are
+// expanded, but the path is not required to exist.
+func (sc *Subcommand) File(assignmentVar *string, shortName string, longName string, description string) {
+	sc.add((*filePath)(assignmentVar), shortName, longName, description)
+}
+
+// ExistingFile adds a new file path flag that must refer to an existing,
+// readable regular file.  Environment variables and a leading ~ are expanded.
+func (sc *Subcommand) ExistingFile(assignmentVar *string, shortName string, longName string, description string) {
+	sc.add((*existingFilePath)(assignmentVar), shortName, longName, description)
+}
+
+// ExistingDir adds a new directory path flag that must refer to an existing,
+// readable directory.  Environment variables and a leading ~ are expanded.
+func (sc *Subcommand) ExistingDir(assignmentVar *string, shortName string, longName string, description string) {
+	sc.add((*existingDirPath)(assignmentVar), shortName, longName, description)
+}
+
+// OutputFile adds a new file path flag that must refer to a writable regular
+// file, or to a file that does not exist yet within an existing directory.
+// Environment variables and a leading ~ are expanded.
+func (sc *Subcommand) OutputFile(assignmentVar *string, shortName string, longName string, description string) {
+	sc.add((*outputFilePath)(assignmentVar), shortName, longName, description)
+}
+
+// HexBytes adds a new bytes flag that takes hex encoded input, with or without
+// a leading 0x.  Example value: 0xdeadbeef
+func (sc *Subcommand) HexBytes(assignmentVar *[]byte, shortName string, longName string, description string) {
+	sc.add((*hexBytes)(assignmentVar), shortName, longName, description)
+}
+
+// Base64Bytes adds a new bytes flag that takes standard or URL safe base64
+// encoded input.
+func (sc *Subcommand) Base64Bytes(assignmentVar *[]byte, shortName string, longName string, description string) {
+	sc.add((*base64Bytes)(assignmentVar), shortName, longName, description)
+}
+
+// BytesFromFile adds a new bytes flag that takes a file path as input and is
+// set to the contents of that file.
+func (sc *Subcommand) BytesFromFile(assignmentVar *[]byte, shortName string, longName string, description string) {
+	sc.add((*fileBytes)(assignmentVar), shortName, longName, description)
+}
+
 // Duration adds a new time.Duration flag.
 // Input format is described in time.ParseDuration().
 // Example values: 1h, 1h50m, 32s
@@ -638,17 +497,77 @@ func (sc *Subcommand) HardwareAddrSlice(assignmentVar *[]net.HardwareAddr, short
 	sc.add(assignmentVar, shortName, longName, description)
 }
 
-// IPMask adds a new net.IPMask flag. IPv4 Only.
+// IPMask adds a new net.IPMask flag.  Accepts IPv4 masks such as
+// 255.255.255.0 and IPv6 masks such as ffff:ffff:ffff:ffff::
 func (sc *Subcommand) IPMask(assignmentVar *net.IPMask, shortName string, longName string, description string) {
 	sc.add(assignmentVar, shortName, longName, description)
 }
 
-// IPMaskSlice adds a new net.HardwareAddr slice flag. IPv4 only.
+// IPMaskSlice adds a new net.IPMask slice flag.
 // Specify the flag multiple times to fill the slice.
 func (sc *Subcommand) IPMaskSlice(assignmentVar *[]net.IPMask, shortName string, longName string, description string) {
 	sc.add(assignmentVar, shortName, longName, description)
 }
 
+// IPNet adds a new net.IPNet flag that takes CIDR notation.
+// Example values: 10.0.0.0/8, fd00::/8
+func (sc *Subcommand) IPNet(assignmentVar *net.IPNet, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// IPNetSlice adds a new net.IPNet slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) IPNetSlice(assignmentVar *[]net.IPNet, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// TCPAddr adds a new net.TCPAddr flag that takes an ip:port.  Host names are
+// not supported, so parsing never performs DNS lookups.
+// Example values: 127.0.0.1:8080, [::1]:443, :80
+func (sc *Subcommand) TCPAddr(assignmentVar *net.TCPAddr, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// TCPAddrSlice adds a new net.TCPAddr slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) TCPAddrSlice(assignmentVar *[]net.TCPAddr, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// Addr adds a new netip.Addr flag.
+func (sc *Subcommand) Addr(assignmentVar *netip.Addr, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// AddrSlice adds a new netip.Addr slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) AddrSlice(assignmentVar *[]netip.Addr, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// Prefix adds a new netip.Prefix flag that takes CIDR notation.
+func (sc *Subcommand) Prefix(assignmentVar *netip.Prefix, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// PrefixSlice adds a new netip.Prefix slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) PrefixSlice(assignmentVar *[]netip.Prefix, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// URL adds a new url.URL flag.  The URL must be absolute.
+// Example values: https://example.com/path
+func (sc *Subcommand) URL(assignmentVar *url.URL, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
+// URLSlice adds a new url.URL slice flag.
+// Specify the flag multiple times to fill the slice.
+func (sc *Subcommand) URLSlice(assignmentVar *[]url.URL, shortName string, longName string, description string) {
+	sc.add(assignmentVar, shortName, longName, description)
+}
+
 // AddPositionalValue adds a positional value to the subcommand.  the
 // relativePosition starts at 1 and is relative to the subcommand it belongs to
 func (sc *Subcommand) AddPositionalValue(assignmentVar *string, name string, relativePosition int, required bool, description string) {
@@ -680,8 +599,17 @@ func (sc *Subcommand) AddPositionalValue(assignmentVar *string, name string, rel
 
 // SetValueForKey sets the value for the specified key. If setting a bool
 // value, then send "true" or "false" as strings.  The returned bool indicates
-// that a value was set.
+// that a value was set.  The flag's Source becomes SourceConfig.  Use
+// SetValueForKeyFrom to record another source, such as SourceEnv.
 func (sc *Subcommand) SetValueForKey(key string, value string) (bool, error) {
+	return sc.SetValueForKeyFrom(key, value, SourceConfig)
+}
+
+// SetValueForKeyFrom sets the value for the specified key like SetValueForKey
+// and records the supplied source as where the value came from.  This lets
+// loaders of environment variables or configuration files set flags without
+// them appearing to have been supplied on the command line.
+func (sc *Subcommand) SetValueForKeyFrom(key string, value string, source ValueSource) (bool, error) {
 
 	// debugPrint("Looking to set key", key, "to value", value)
 	// check for and assign flags that match the key
@@ -689,7 +617,11 @@ func (sc *Subcommand) SetValueForKey(key string, value string) (bool, error) {
 		// debugPrint("Evaluating string flag", f.ShortName, "==", key, "||", f.LongName, "==", key)
 		if f.ShortName == key || f.LongName == key {
 			// debugPrint("Setting string value for", key, "to", value)
-			f.identifyAndAssignValue(value)
+			err := f.identifyAndAssignValue(value)
+			if err != nil {
+				return true, err
+			}
+			f.source = source
 			return true, nil
 		}
 	}
@@ -698,58 +630,50 @@ func (sc *Subcommand) SetValueForKey(key string, value string) (bool, error) {
 	return false, nil
 }
 
-// ensureNoConflictWithBuiltinHelp ensures that the flags on this subcommand do
-// not conflict with the builtin help flags (-h or --help). Exits the program
-// if a conflict is found.
-func (sc *Subcommand) ensureNoConflictWithBuiltinHelp() {
+// conflictWithBuiltinHelp returns an error when a flag on this subcommand
+// conflicts with the builtin help flags (-h or --help)
+func (sc *Subcommand) conflictWithBuiltinHelp() error {
 	for _, f := range sc.Flags {
-		if f.LongName == helpFlagLongName {
-			sc.exitBecauseOfHelpFlagConflict(f.LongName)
-		}
-		if f.LongName == helpFlagShortName {
-			sc.exitBecauseOfHelpFlagConflict(f.LongName)
-		}
-		if f.ShortName == helpFlagLongName {
-			sc.exitBecauseOfHelpFlagConflict(f.ShortName)
+		if f.LongName == helpFlagLongName || f.LongName == helpFlagShortName {
+			return helpFlagConflictError(f.LongName)
 		}
-		if f.ShortName == helpFlagShortName {
-			sc.exitBecauseOfHelpFlagConflict(f.ShortName)
+		if f.ShortName == helpFlagLongName || f.ShortName == helpFlagShortName {
+			return helpFlagConflictError(f.ShortName)
 		}
 	}
+	return nil
 }
 
-// ensureNoConflictWithBuiltinVersion ensures that the flags on this subcommand do
-// not conflict with the builtin version flag (--version). Exits the program
-// if a conflict is found.
-func (sc *Subcommand) ensureNoConflictWithBuiltinVersion() {
+// conflictWithBuiltinVersion returns an error when a flag on this subcommand
+// conflicts with the builtin version flag (--version)
+func (sc *Subcommand) conflictWithBuiltinVersion() error {
 	for _, f := range sc.Flags {
 		if f.LongName == versionFlagLongName {
-			sc.exitBecauseOfVersionFlagConflict(f.LongName)
+			return versionFlagConflictError(f.LongName)
 		}
 		if f.ShortName == versionFlagLongName {
-			sc.exitBecauseOfVersionFlagConflict(f.ShortName)
+			return versionFlagConflictError(f.ShortName)
 		}
 	}
+	return nil
 }
 
-// exitBecauseOfVersionFlagConflict exits the program with a message about how to prevent
-// flags being defined from conflicting with the builtin flags.
-func (sc *Subcommand) exitBecauseOfVersionFlagConflict(flagName string) {
-	fmt.Println(`Flag with name '` + flagName + `' conflicts with the internal --version flag in flaggy.
+// versionFlagConflictError describes how to prevent flags being defined from
+// conflicting with the builtin version flag
+func versionFlagConflictError(flagName string) error {
+	return errors.New(`Flag with name '` + flagName + `' conflicts with the internal --version flag in flaggy.
 
 You must either change the flag's name, or disable flaggy's internal version
 flag with 'flaggy.DefaultParser.ShowVersionWithVersionFlag = false'.  If you are using
 a custom parser, you must instead set '.ShowVersionWithVersionFlag = false' on it.`)
-	exitOrPanic(1)
 }
 
-// exitBecauseOfHelpFlagConflict exits the program with a message about how to prevent
-// flags being defined from conflicting with the builtin flags.
-func (sc *Subcommand) exitBecauseOfHelpFlagConflict(flagName string) {
-	fmt.Println(`Flag with name '` + flagName + `' conflicts with the internal --help or -h flag in flaggy.
+// helpFlagConflictError describes how to prevent flags being defined from
+// conflicting with the builtin help flags
+func helpFlagConflictError(flagName string) error {
+	return errors.New(`Flag with name '` + flagName + `' conflicts with the internal --help or -h flag in flaggy.
 
 You must either change the flag's name, or disable flaggy's internal help
 flag with 'flaggy.DefaultParser.ShowHelpWithHFlag = false'.  If you are using
 a custom parser, you must instead set '.ShowHelpWithHFlag = false' on it.`)
-	exitOrPanic(1)
 }
@@ -0,0 +1,9 @@
+//go:build darwin || dragonfly || freebsd || netbsd
+
+package flaggy
+
+import "syscall"
+
+// the ioctl requests that get and set terminal attributes
+const ioctlGetTermios = syscall.TIOCGETA
+const ioctlSetTermios = syscall.TIOCSETA
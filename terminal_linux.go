@@ -0,0 +1,7 @@
+package flaggy
+
+import "syscall"
+
+// the ioctl requests that get and set terminal attributes
+const ioctlGetTermios = syscall.TCGETS
+const ioctlSetTermios = syscall.TCSETS
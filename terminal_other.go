@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !windows
+
+package flaggy
+
+import (
+	"io"
+	"os"
+)
+
+// isTerminalFile returns false, because terminals can not be detected on
+// this platform
+func isTerminalFile(f *os.File) bool {
+	return false
+}
+
+// disableEcho does nothing, because terminals are not detected on this
+// platform
+func disableEcho(r io.Reader) (func(), error) {
+	return func() {}, nil
+}
+
+// interruptibleFile returns false, because waiting for terminal input can
+// not be stopped on this platform
+func interruptibleFile(f *os.File) (*os.File, func(), bool) {
+	return nil, nil, false
+}
@@ -0,0 +1,92 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd
+
+package flaggy
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// isTerminalFile determines if the file is a terminal by asking for its
+// terminal attributes, which only terminals have
+func isTerminalFile(f *os.File) bool {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		var termios syscall.Termios
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios)))
+	})
+	return err == nil && errno == 0
+}
+
+// disableEcho stops a terminal from displaying what is typed into it and
+// returns a func that restores it.  Readers that are not terminals are left
+// alone.
+func disableEcho(r io.Reader) (func(), error) {
+	f, ok := r.(*os.File)
+	if !ok || !isTerminalFile(f) {
+		return func() {}, nil
+	}
+	fd := f.Fd()
+
+	var original syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&original)))
+	if errno != 0 {
+		return nil, errno
+	}
+	hidden := original
+	hidden.Lflag &^= syscall.ECHO
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&hidden)))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return func() {
+		syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&original)))
+	}, nil
+}
+
+// interruptibleFile opens a terminal again so that it supports read
+// deadlines, so that waiting for input can be stopped, and returns a func
+// that closes it.  The terminal is opened through /dev/tty, which gives the
+// new file its own status flags, so making it non-blocking does not change
+// the blocking mode of f or of other processes sharing the terminal.  False
+// is returned for files that are not the controlling terminal.
+func interruptibleFile(f *os.File) (*os.File, func(), bool) {
+	if !isControllingTerminal(f) {
+		return nil, nil, false
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, nil, false
+	}
+	if tty.SetReadDeadline(time.Time{}) != nil {
+		tty.Close()
+		return nil, nil, false
+	}
+	return tty, func() {
+		tty.Close()
+	}, true
+}
+
+// isControllingTerminal determines if the file is the controlling terminal
+// of this process by asking for its foreground process group, which only the
+// controlling terminal will tell
+func isControllingTerminal(f *os.File) bool {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		var pgrp int32
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	})
+	return err == nil && errno == 0
+}
@@ -0,0 +1,49 @@
+package flaggy
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// enableEchoInput is the console mode that displays what is typed
+const enableEchoInput = 0x4
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// isTerminalFile determines if the file is a console by asking for its
+// console mode, which only consoles have
+func isTerminalFile(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+// disableEcho stops a console from displaying what is typed into it and
+// returns a func that restores it.  Readers that are not consoles are left
+// alone.
+func disableEcho(r io.Reader) (func(), error) {
+	f, ok := r.(*os.File)
+	if !ok {
+		return func() {}, nil
+	}
+	handle := syscall.Handle(f.Fd())
+
+	var original uint32
+	if syscall.GetConsoleMode(handle, &original) != nil {
+		return func() {}, nil
+	}
+	succeeded, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(original&^enableEchoInput))
+	if succeeded == 0 {
+		return nil, err
+	}
+
+	return func() {
+		procSetConsoleMode.Call(uintptr(handle), uintptr(original))
+	}, nil
+}
+
+// interruptibleFile returns false, because waiting for console input can not
+// be stopped on this platform
+func interruptibleFile(f *os.File) (*os.File, func(), bool) {
+	return nil, nil, false
+}
@@ -0,0 +1,109 @@
+package flaggy
+
+import (
+	"io"
+	"os"
+	"text/template"
+)
+
+// ANSI escape sequences used to build themes
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiUnderline = "\x1b[4m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiYellow    = "\x1b[33m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// noColorEnvVar is the environment variable that disables all color output
+// when set to any non-empty value.  See https://no-color.org.
+const noColorEnvVar = "NO_COLOR"
+
+// Theme holds the ANSI escape sequences used to colorize help and error
+// output.  A blank field leaves that part of the output uncolored.  Colors
+// are only rendered when the output is a terminal and the NO_COLOR
+// environment variable is not set.
+type Theme struct {
+	Heading    string // section headings such as "Flags:"
+	Command    string // the command name and subcommand names
+	Flag       string // flag names
+	Positional string // positional value names
+	Error      string // error messages displayed with help
+	Force      bool   // render colors even when the output is not a terminal
+}
+
+// NewTheme creates a new Theme with the default set of colors
+func NewTheme() *Theme {
+	return &Theme{
+		Heading:    ansiBold + ansiUnderline,
+		Command:    ansiBold + ansiCyan,
+		Flag:       ansiGreen,
+		Positional: ansiYellow,
+		Error:      ansiBold + ansiRed,
+	}
+}
+
+// enabled determines if the theme's colors should be written to the supplied
+// writer.  Color is never enabled for a nil theme or when NO_COLOR is set.
+func (t *Theme) enabled(w io.Writer) bool {
+	if t == nil {
+		return false
+	}
+	if os.Getenv(noColorEnvVar) != "" {
+		return false
+	}
+	if t.Force {
+		return true
+	}
+	return isTerminal(w)
+}
+
+// isTerminal determines if the supplied reader or writer is a terminal.
+// Other character devices, such as /dev/null, are not terminals.
+func isTerminal(rw interface{}) bool {
+	f, ok := rw.(*os.File)
+	return ok && isTerminalFile(f)
+}
+
+// colorize wraps the supplied string in the specified color code.  Blank
+// strings and blank color codes are returned unchanged.
+func colorize(color string, s string) string {
+	if color == "" || s == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// templateFuncs returns the functions available to help templates.  When
+// color is false, every function returns its input unchanged so that
+// templates render the same as they would without any theme.
+func (t *Theme) templateFuncs(color bool) template.FuncMap {
+	paint := func(code func(*Theme) string) func(string) string {
+		return func(s string) string {
+			if !color {
+				return s
+			}
+			return colorize(code(t), s)
+		}
+	}
+	return template.FuncMap{
+		"bold":       paint(func(*Theme) string { return ansiBold }),
+		"underline":  paint(func(*Theme) string { return ansiUnderline }),
+		"heading":    paint(func(t *Theme) string { return t.Heading }),
+		"command":    paint(func(t *Theme) string { return t.Command }),
+		"flagName":   paint(func(t *Theme) string { return t.Flag }),
+		"positional": paint(func(t *Theme) string { return t.Positional }),
+		"errorText":  paint(func(t *Theme) string { return t.Error }),
+	}
+}
+
+// colorizeError colors an error message for the supplied writer with the
+// theme's error color
+func (t *Theme) colorizeError(w io.Writer, message string) string {
+	if !t.enabled(w) {
+		return message
+	}
+	return colorize(t.Error, message)
+}
@@ -0,0 +1,77 @@
+package flaggy
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestThemeColorizesHelp(t *testing.T) {
+	t.Setenv(noColorEnvVar, "")
+
+	var test string
+	p := NewParser("testThemeColorizesHelp")
+	p.String(&test, "t", "test", "a test flag")
+
+	// no theme renders plain help
+	var buf bytes.Buffer
+	err := p.writeHelp(&buf, "an error happened")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatal("help without a theme contained color codes:", buf.String())
+	}
+
+	// a theme with a non-terminal output renders plain help
+	p.Theme = NewTheme()
+	buf.Reset()
+	err = p.writeHelp(&buf, "an error happened")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatal("help written to a non-terminal contained color codes:", buf.String())
+	}
+
+	// a forced theme renders colored help
+	p.Theme.Force = true
+	buf.Reset()
+	err = p.writeHelp(&buf, "an error happened")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), colorize(p.Theme.Flag, "--test")) {
+		t.Fatal("flag name was not colorized:", buf.String())
+	}
+	if !strings.Contains(buf.String(), colorize(p.Theme.Error, "an error happened")) {
+		t.Fatal("error message was not colorized:", buf.String())
+	}
+
+	// help can be written from several goroutines at once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out bytes.Buffer
+			err := p.writeHelp(&out, "")
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestThemeRespectsNoColor(t *testing.T) {
+	t.Setenv(noColorEnvVar, "1")
+
+	theme := NewTheme()
+	theme.Force = true
+	var buf bytes.Buffer
+	if theme.enabled(&buf) {
+		t.Fatal("theme was enabled while NO_COLOR was set")
+	}
+}
@@ -0,0 +1,106 @@
+package flaggy
+
+import (
+	"errors"
+	"sort"
+)
+
+// SkipSubcommands can be returned by the func passed to Parser.Walk to skip
+// the child subcommands of the subcommand being visited.  Walk does not
+// return it as an error.
+var SkipSubcommands = errors.New("skip subcommands")
+
+// Walk calls fn for the parser and every subcommand beneath it.  The path
+// passed to fn starts with the parser's own subcommand and ends with the
+// subcommand being visited.  Subcommands are visited depth first, each one
+// before its children, with children in the order they were attached.  Hidden
+// subcommands are included.  Walking stops at the first error returned by fn,
+// which is returned by Walk.
+func (p *Parser) Walk(fn func(path []*Subcommand) error) error {
+	err := walkSubcommands([]*Subcommand{&p.Subcommand}, fn)
+	if err == SkipSubcommands {
+		return nil
+	}
+	return err
+}
+
+// walkSubcommands calls fn for the last subcommand in the path, then walks
+// each of its children
+func walkSubcommands(path []*Subcommand, fn func(path []*Subcommand) error) error {
+	err := fn(path)
+	if err == SkipSubcommands {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sc := path[len(path)-1]
+	for _, child := range sc.Subcommands {
+		// copy the path so fn can safely keep it
+		childPath := make([]*Subcommand, len(path), len(path)+1)
+		copy(childPath, path)
+		err = walkSubcommands(append(childPath, child), fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindSubcommand returns the subcommand found by following the supplied
+// names, or short names, down from the parser.  The parser's own subcommand
+// is returned when no names are supplied, and nil is returned when any name
+// along the path is not found.
+func (p *Parser) FindSubcommand(path ...string) *Subcommand {
+	current := &p.Subcommand
+	for _, name := range path {
+		var next *Subcommand
+		for _, sc := range current.Subcommands {
+			if sc.Name == name || (sc.ShortName != "" && sc.ShortName == name) {
+				next = sc
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// VisitFlags calls fn for each flag of this subcommand in the order the flags
+// were added.  Hidden flags are included.
+func (sc *Subcommand) VisitFlags(fn func(*Flag)) {
+	for _, f := range sc.Flags {
+		fn(f)
+	}
+}
+
+// VisitAllFlags calls fn for each flag that can be used with this
+// subcommand.  The subcommand's own flags are visited first, followed by the
+// flags that are not local of each parent subcommand up to the parser.
+// Each subcommand's flags are visited in the order they were added.
+func (sc *Subcommand) VisitAllFlags(fn func(*Flag)) {
+	sc.VisitFlags(fn)
+	for parent := sc.parent; parent != nil; parent = parent.parent {
+		parent.VisitFlags(func(f *Flag) {
+			if f.Scope != ScopeLocal {
+				fn(f)
+			}
+		})
+	}
+}
+
+// VisitPositionals calls fn for each positional value of this subcommand in
+// order of position.  Hidden positional values are included.
+func (sc *Subcommand) VisitPositionals(fn func(*PositionalValue)) {
+	positionals := make([]*PositionalValue, len(sc.PositionalFlags))
+	copy(positionals, sc.PositionalFlags)
+	sort.SliceStable(positionals, func(i, j int) bool {
+		return positionals[i].Position < positionals[j].Position
+	})
+	for _, pv := range positionals {
+		fn(pv)
+	}
+}
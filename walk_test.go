@@ -0,0 +1,134 @@
+package flaggy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/integrii/flaggy"
+)
+
+// newWalkParser creates a parser with a small tree of subcommands
+func newWalkParser() *flaggy.Parser {
+	p := flaggy.NewParser("app")
+	flaggy.AddP(&p.Subcommand, "c", "config", "", "config flag")
+	server := flaggy.NewSubcommand("server")
+	server.ShortName = "s"
+	flaggy.AddP(server, "p", "port", 80, "port flag")
+	start := flaggy.NewSubcommand("start")
+	flaggy.AddP(start, "d", "detach", false, "detach flag")
+	var name, mode string
+	start.AddPositionalValue(&mode, "mode", 2, false, "mode positional")
+	start.AddPositionalValue(&name, "name", 1, false, "name positional")
+	stop := flaggy.NewSubcommand("stop")
+	server.AttachSubcommand(start, 1)
+	server.AttachSubcommand(stop, 1)
+	client := flaggy.NewSubcommand("client")
+	p.AttachSubcommand(server, 1)
+	p.AttachSubcommand(client, 1)
+	return p
+}
+
+func TestWalk(t *testing.T) {
+	p := newWalkParser()
+
+	var visited []string
+	err := p.Walk(func(path []*flaggy.Subcommand) error {
+		var names []string
+		for _, sc := range path {
+			names = append(names, sc.Name)
+		}
+		visited = append(visited, strings.Join(names, " "))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "app|app server|app server start|app server stop|app client"
+	if strings.Join(visited, "|") != expected {
+		t.Fatal("walk order incorrect:", visited)
+	}
+
+	// skipping children and stopping early
+	visited = nil
+	stop := errors.New("stop")
+	err = p.Walk(func(path []*flaggy.Subcommand) error {
+		sc := path[len(path)-1]
+		visited = append(visited, sc.Name)
+		if sc.Name == "server" {
+			return flaggy.SkipSubcommands
+		}
+		if sc.Name == "client" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatal("walk did not return the error from fn", err)
+	}
+	if strings.Join(visited, " ") != "app server client" {
+		t.Fatal("walk did not skip subcommands:", visited)
+	}
+}
+
+func TestFindSubcommand(t *testing.T) {
+	p := newWalkParser()
+	if p.FindSubcommand() != &p.Subcommand {
+		t.Fatal("empty path did not return the parser")
+	}
+	sc := p.FindSubcommand("s", "start")
+	if sc == nil || sc.Name != "start" {
+		t.Fatal("subcommand not found by path", sc)
+	}
+	if p.FindSubcommand("server", "missing") != nil {
+		t.Fatal("missing subcommand was found")
+	}
+}
+
+func TestVisitFlags(t *testing.T) {
+	p := newWalkParser()
+	start := p.FindSubcommand("server", "start")
+
+	var names []string
+	start.VisitFlags(func(f *flaggy.Flag) {
+		names = append(names, f.LongName)
+	})
+	if strings.Join(names, " ") != "detach" {
+		t.Fatal("visited flags incorrect:", names)
+	}
+
+	names = nil
+	start.VisitAllFlags(func(f *flaggy.Flag) {
+		names = append(names, f.LongName)
+	})
+	if strings.Join(names, " ") != "detach port config" {
+		t.Fatal("visited all flags incorrect:", names)
+	}
+
+	// local flags of parents can not be used with start
+	server := p.FindSubcommand("server")
+	flaggy.AddP(server, "o", "output", "", "output flag")
+	server.SetFlagScope(flaggy.ScopeLocal, "output")
+	names = nil
+	start.VisitAllFlags(func(f *flaggy.Flag) {
+		names = append(names, f.LongName)
+	})
+	if strings.Join(names, " ") != "detach port config" {
+		t.Fatal("visited a local flag of a parent:", names)
+	}
+	names = nil
+	server.VisitAllFlags(func(f *flaggy.Flag) {
+		names = append(names, f.LongName)
+	})
+	if strings.Join(names, " ") != "port output config" {
+		t.Fatal("did not visit a local flag of the subcommand:", names)
+	}
+
+	names = nil
+	start.VisitPositionals(func(pv *flaggy.PositionalValue) {
+		names = append(names, pv.Name)
+	})
+	if strings.Join(names, " ") != "name mode" {
+		t.Fatal("visited positionals incorrect:", names)
+	}
+}